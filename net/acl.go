@@ -0,0 +1,335 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+)
+
+// Action is what a Policy does with a packet.
+type Action int
+
+const (
+	// ActionDeny drops the packet.
+	ActionDeny Action = iota
+	// ActionAllow accepts the packet.
+	ActionAllow
+)
+
+// String returns the name of a.
+func (a Action) String() string {
+	switch a {
+	case ActionDeny:
+		return "deny"
+	case ActionAllow:
+		return "allow"
+	default:
+		return "Action(" + strconv.Itoa(int(a)) + ")"
+	}
+}
+
+// Protocol is an IP protocol number.
+type Protocol uint8
+
+// Well-known protocol numbers. Although 0 is assigned to the IPv6
+// Hop-by-Hop Option, a Rule uses it to match any protocol.
+const (
+	ProtocolAny    Protocol = 0
+	ProtocolICMP   Protocol = 1
+	ProtocolTCP    Protocol = 6
+	ProtocolUDP    Protocol = 17
+	ProtocolICMPv6 Protocol = 58
+)
+
+// String returns the name of p, or its number if it has none.
+func (p Protocol) String() string {
+	switch p {
+	case ProtocolAny:
+		return "any"
+	case ProtocolICMP:
+		return "icmp"
+	case ProtocolTCP:
+		return "tcp"
+	case ProtocolUDP:
+		return "udp"
+	case ProtocolICMPv6:
+		return "icmpv6"
+	default:
+		return strconv.Itoa(int(p))
+	}
+}
+
+// hasPorts reports whether p carries port numbers.
+func (p Protocol) hasPorts() bool {
+	return p == ProtocolTCP || p == ProtocolUDP
+}
+
+// portSet returns the ports rs match, every port if rs is empty.
+func portSet(rs []PortRange) PortSet {
+	if len(rs) == 0 {
+		return PortSet{}.Complement()
+	}
+	return PortSet{mergePorts(slices.Clone(rs))}
+}
+
+// portsCover reports whether the ports a cover every port in b.
+func portsCover(a, b []PortRange) bool {
+	return portSet(a).ContainsSet(portSet(b))
+}
+
+// portsOverlap reports whether a and b share a port.
+func portsOverlap(a, b []PortRange) bool {
+	return portSet(a).Overlaps(portSet(b))
+}
+
+// Rule is an entry in a Policy. Empty address and port lists match
+// anything, so the zero Rule denies every packet.
+type Rule struct {
+	Name     string         // an optional label, used in comments
+	Action   Action         // what to do with matching packets
+	Src      []netip.Prefix // the source prefixes
+	Dst      []netip.Prefix // the destination prefixes
+	Protocol Protocol       // the protocol, or ProtocolAny
+	SrcPorts []PortRange    // the source ports; TCP and UDP only
+	DstPorts []PortRange    // the destination ports; TCP and UDP only
+}
+
+// Packet is the 5-tuple a Policy is evaluated against. The ports are
+// ignored unless Protocol is TCP or UDP.
+type Packet struct {
+	Src      netip.Addr
+	Dst      netip.Addr
+	Protocol Protocol
+	SrcPort  uint16
+	DstPort  uint16
+}
+
+// validate checks the prefixes and ports of r.
+func (r *Rule) validate() error {
+	for _, p := range slices.Concat(r.Src, r.Dst) {
+		if !p.IsValid() {
+			return errors.New("invalid netip.Prefix")
+		}
+	}
+	if (len(r.SrcPorts) > 0 || len(r.DstPorts) > 0) && !r.Protocol.hasPorts() {
+		return fmt.Errorf("ports require tcp or udp, not %s", r.Protocol)
+	}
+	for _, pr := range slices.Concat(r.SrcPorts, r.DstPorts) {
+		if pr.From > pr.To {
+			return fmt.Errorf("invalid port range %d-%d", pr.From, pr.To)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether pkt matches r.
+func (r *Rule) Matches(pkt Packet) bool {
+	if r.Protocol != ProtocolAny && r.Protocol != pkt.Protocol {
+		return false
+	}
+	if !matchPrefixes(r.Src, pkt.Src) || !matchPrefixes(r.Dst, pkt.Dst) {
+		return false
+	}
+	return matchPorts(r.SrcPorts, pkt.SrcPort) && matchPorts(r.DstPorts, pkt.DstPort)
+}
+
+func matchPrefixes(ps []netip.Prefix, addr netip.Addr) bool {
+	addr = addr.WithZone("")
+	return len(ps) == 0 || slices.ContainsFunc(ps, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
+
+func matchPorts(rs []PortRange, port uint16) bool {
+	return len(rs) == 0 || slices.ContainsFunc(rs, func(r PortRange) bool { return r.Contains(port) })
+}
+
+// addrSet returns the addresses ps match, every address if ps is empty.
+func addrSet(ps []netip.Prefix) IPSet {
+	if len(ps) == 0 {
+		return IPSet{}.Complement()
+	}
+	s, _ := NewIPSet(ps...)
+	return s
+}
+
+// Policy is an ordered list of rules. The first matching rule decides
+// the fate of a packet; packets matching no rule receive Default.
+type Policy struct {
+	Rules   []Rule
+	Default Action
+}
+
+// Validate checks every rule of p.
+func (p *Policy) Validate() error {
+	for i := range p.Rules {
+		if err := p.Rules[i].validate(); err != nil {
+			return fmt.Errorf("rule %d%s: %w", i, ruleLabel(&p.Rules[i]), err)
+		}
+	}
+	return nil
+}
+
+// ruleLabel returns " (name)" for a named rule.
+func ruleLabel(r *Rule) string {
+	if r.Name == "" {
+		return ""
+	}
+	return " (" + r.Name + ")"
+}
+
+// Evaluate returns the index of the first rule in p matching pkt,
+// together with its action. If no rule matches, it returns -1 and
+// p.Default.
+func (p *Policy) Evaluate(pkt Packet) (int, Action) {
+	for i := range p.Rules {
+		if p.Rules[i].Matches(pkt) {
+			return i, p.Rules[i].Action
+		}
+	}
+	return -1, p.Default
+}
+
+// FindingKind classifies a Finding.
+type FindingKind int
+
+const (
+	// FindingShadowed marks a rule that never matches because earlier
+	// rules with a different action take every packet it would.
+	FindingShadowed FindingKind = iota
+	// FindingRedundant marks a rule whose removal would not change the
+	// fate of any packet.
+	FindingRedundant
+)
+
+// String returns the name of k.
+func (k FindingKind) String() string {
+	switch k {
+	case FindingShadowed:
+		return "shadowed"
+	case FindingRedundant:
+		return "redundant"
+	default:
+		return "FindingKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// Finding reports a dead rule.
+type Finding struct {
+	Rule int         // the index of the dead rule
+	Kind FindingKind // why it is dead
+	By   []int       // the earlier rules that take its packets, if any
+}
+
+// String describes f.
+func (f Finding) String() string {
+	if len(f.By) == 0 {
+		return fmt.Sprintf("rule %d is %s: it repeats the default action", f.Rule, f.Kind)
+	}
+	return fmt.Sprintf("rule %d is %s by rules %v", f.Rule, f.Kind, f.By)
+}
+
+// Analyze reports the rules of p that never decide the fate of a
+// packet. A rule is dead if earlier rules, alone or together, match
+// every packet it matches; it is shadowed if any of them has a
+// different action and redundant otherwise. A rule is also redundant
+// if it repeats the default action and no later rule with a different
+// action overlaps it.
+//
+// Coverage by several rules is recognized when they jointly cover the
+// source or the destination prefixes of the dead rule, each matching
+// all its other fields. The analysis is sound, so every finding is
+// genuine, but it may miss rules made dead by more intricate overlaps.
+func (p *Policy) Analyze() ([]Finding, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	var out []Finding
+	for j := range p.Rules {
+		if f, ok := p.covered(j); ok {
+			out = append(out, f)
+			continue
+		}
+		if p.Rules[j].Action == p.Default && !p.overlapsLater(j) {
+			out = append(out, Finding{Rule: j, Kind: FindingRedundant})
+		}
+	}
+	return out, nil
+}
+
+// covered checks whether the rules preceding rule j cover it.
+func (p *Policy) covered(j int) (Finding, bool) {
+	r := &p.Rules[j]
+	for _, dim := range []struct {
+		prefixes func(*Rule) []netip.Prefix
+		others   func(a, b *Rule) bool
+	}{
+		{func(r *Rule) []netip.Prefix { return r.Src }, func(a, b *Rule) bool {
+			return addrSet(a.Dst).ContainsSet(addrSet(b.Dst)) && coversNonAddr(a, b)
+		}},
+		{func(r *Rule) []netip.Prefix { return r.Dst }, func(a, b *Rule) bool {
+			return addrSet(a.Src).ContainsSet(addrSet(b.Src)) && coversNonAddr(a, b)
+		}},
+	} {
+		want := addrSet(dim.prefixes(r))
+		var (
+			have IPSet
+			by   []int
+		)
+		for i := range j {
+			q := &p.Rules[i]
+			if !dim.others(q, r) {
+				continue
+			}
+			qs := addrSet(dim.prefixes(q))
+			if !qs.Overlaps(want) {
+				continue
+			}
+			have = have.Union(qs)
+			by = append(by, i)
+			if have.ContainsSet(want) {
+				kind := FindingRedundant
+				for _, b := range by {
+					if p.Rules[b].Action != r.Action {
+						kind = FindingShadowed
+					}
+				}
+				return Finding{Rule: j, Kind: kind, By: by}, true
+			}
+		}
+	}
+	return Finding{}, false
+}
+
+// coversNonAddr reports whether a matches every protocol and port b
+// does.
+func coversNonAddr(a, b *Rule) bool {
+	if a.Protocol != ProtocolAny && a.Protocol != b.Protocol {
+		return false
+	}
+	return portsCover(a.SrcPorts, b.SrcPorts) && portsCover(a.DstPorts, b.DstPorts)
+}
+
+// overlapsLater reports whether a rule after rule j, with a different
+// action, matches some packet that rule j does.
+func (p *Policy) overlapsLater(j int) bool {
+	r := &p.Rules[j]
+	for _, q := range p.Rules[j+1:] {
+		if q.Action == r.Action {
+			continue
+		}
+		if q.Protocol != ProtocolAny && r.Protocol != ProtocolAny && q.Protocol != r.Protocol {
+			continue
+		}
+		if !addrSet(q.Src).Overlaps(addrSet(r.Src)) || !addrSet(q.Dst).Overlaps(addrSet(r.Dst)) {
+			continue
+		}
+		if portsOverlap(q.SrcPorts, r.SrcPorts) && portsOverlap(q.DstPorts, r.DstPorts) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+// samplePolicy is a small edge policy used by the ACL tests.
+func samplePolicy(t *testing.T) *Policy {
+	t.Helper()
+	return &Policy{
+		Default: ActionDeny,
+		Rules: []Rule{
+			{Name: "block bogons", Action: ActionDeny, Src: parsePrefixes(t, "10.0.0.0/8", "fc00::/7")},
+			{Name: "ssh from office", Action: ActionAllow, Src: parsePrefixes(t, "192.0.2.0/24"), Protocol: ProtocolTCP, DstPorts: []PortRange{{22, 22}}},
+			{Name: "web", Action: ActionAllow, Dst: parsePrefixes(t, "198.51.100.0/24", "2001:db8::/32"), Protocol: ProtocolTCP, DstPorts: []PortRange{{80, 80}, {443, 443}}},
+			{Name: "ping", Action: ActionAllow, Protocol: ProtocolICMP},
+		},
+	}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	p := samplePolicy(t)
+	testpairs := []struct {
+		pkt    Packet
+		rule   int
+		action Action
+	}{
+		{pkt: Packet{Src: netip.MustParseAddr("10.1.2.3"), Dst: netip.MustParseAddr("198.51.100.1"), Protocol: ProtocolTCP, DstPort: 80}, rule: 0, action: ActionDeny},
+		{pkt: Packet{Src: netip.MustParseAddr("192.0.2.7"), Dst: netip.MustParseAddr("203.0.113.1"), Protocol: ProtocolTCP, SrcPort: 50000, DstPort: 22}, rule: 1, action: ActionAllow},
+		{pkt: Packet{Src: netip.MustParseAddr("192.0.2.7"), Dst: netip.MustParseAddr("203.0.113.1"), Protocol: ProtocolUDP, DstPort: 22}, rule: -1, action: ActionDeny},
+		{pkt: Packet{Src: netip.MustParseAddr("203.0.113.9"), Dst: netip.MustParseAddr("198.51.100.1"), Protocol: ProtocolTCP, DstPort: 443}, rule: 2, action: ActionAllow},
+		{pkt: Packet{Src: netip.MustParseAddr("2001:db8:1::1"), Dst: netip.MustParseAddr("2001:db8::80"), Protocol: ProtocolTCP, DstPort: 80}, rule: 2, action: ActionAllow},
+		{pkt: Packet{Src: netip.MustParseAddr("fd00::1"), Dst: netip.MustParseAddr("2001:db8::80"), Protocol: ProtocolTCP, DstPort: 80}, rule: 0, action: ActionDeny},
+		{pkt: Packet{Src: netip.MustParseAddr("203.0.113.9"), Dst: netip.MustParseAddr("198.51.100.1"), Protocol: ProtocolTCP, DstPort: 8080}, rule: -1, action: ActionDeny},
+		{pkt: Packet{Src: netip.MustParseAddr("203.0.113.9"), Dst: netip.MustParseAddr("198.51.100.1"), Protocol: ProtocolICMP}, rule: 3, action: ActionAllow},
+	}
+
+	for _, pair := range testpairs {
+		rule, action := p.Evaluate(pair.pkt)
+		if rule != pair.rule || action != pair.action {
+			t.Errorf("%+v: expected rule %d (%v), got rule %d (%v)", pair.pkt, pair.rule, pair.action, rule, action)
+		}
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	if err := samplePolicy(t).Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []Rule{
+		{Src: []netip.Prefix{{}}},
+		{Protocol: ProtocolICMP, DstPorts: []PortRange{{80, 80}}},
+		{SrcPorts: []PortRange{{80, 80}}},
+		{Protocol: ProtocolTCP, DstPorts: []PortRange{{90, 80}}},
+	} {
+		p := &Policy{Rules: []Rule{r}}
+		if err := p.Validate(); err == nil {
+			t.Errorf("%+v: expected error", r)
+		}
+		if _, err := p.Analyze(); err == nil {
+			t.Errorf("%+v: expected error", r)
+		}
+	}
+}
+
+func TestPolicyAnalyze(t *testing.T) {
+	testpairs := []struct {
+		name     string
+		rules    []Rule
+		deflt    Action
+		expected []Finding
+	}{
+		{
+			name:  "no findings",
+			rules: samplePolicy(t).Rules,
+		},
+		{
+			name: "shadowed by a broader rule",
+			rules: []Rule{
+				{Action: ActionDeny, Src: parsePrefixes(t, "10.0.0.0/8")},
+				{Action: ActionAllow, Src: parsePrefixes(t, "10.1.0.0/16"), Protocol: ProtocolTCP, DstPorts: []PortRange{{22, 22}}},
+			},
+			expected: []Finding{{Rule: 1, Kind: FindingShadowed, By: []int{0}}},
+		},
+		{
+			name: "redundant with an earlier rule",
+			rules: []Rule{
+				{Action: ActionAllow, Protocol: ProtocolTCP, DstPorts: []PortRange{{1, 1024}}},
+				{Action: ActionAllow, Dst: parsePrefixes(t, "192.0.2.1/32"), Protocol: ProtocolTCP, DstPorts: []PortRange{{80, 80}, {443, 443}}},
+			},
+			expected: []Finding{{Rule: 1, Kind: FindingRedundant, By: []int{0}}},
+		},
+		{
+			name: "covered by several rules together",
+			rules: []Rule{
+				{Action: ActionAllow, Src: parsePrefixes(t, "192.0.2.0/25")},
+				{Action: ActionAllow, Src: parsePrefixes(t, "192.0.2.128/25")},
+				{Action: ActionAllow, Src: parsePrefixes(t, "192.0.2.0/24"), Protocol: ProtocolTCP},
+			},
+			expected: []Finding{{Rule: 2, Kind: FindingRedundant, By: []int{0, 1}}},
+		},
+		{
+			name: "partially shadowed by a different action",
+			rules: []Rule{
+				{Action: ActionAllow, Dst: parsePrefixes(t, "2001:db8::/33")},
+				{Action: ActionDeny, Dst: parsePrefixes(t, "2001:db8:8000::/33")},
+				{Action: ActionAllow, Dst: parsePrefixes(t, "2001:db8::/32"), Protocol: ProtocolTCP},
+			},
+			deflt:    ActionAllow,
+			expected: []Finding{{Rule: 0, Kind: FindingRedundant}, {Rule: 2, Kind: FindingShadowed, By: []int{0, 1}}},
+		},
+		{
+			name: "repeats the default",
+			rules: []Rule{
+				{Action: ActionAllow, Src: parsePrefixes(t, "192.0.2.0/24")},
+				{Action: ActionDeny, Src: parsePrefixes(t, "198.51.100.0/24")},
+				{Action: ActionDeny},
+			},
+			expected: []Finding{{Rule: 1, Kind: FindingRedundant}, {Rule: 2, Kind: FindingRedundant}},
+		},
+		{
+			name: "repeats the default but overrides a later rule",
+			rules: []Rule{
+				{Action: ActionDeny, Src: parsePrefixes(t, "192.0.2.66/32")},
+				{Action: ActionAllow, Src: parsePrefixes(t, "192.0.2.0/24")},
+			},
+		},
+		{
+			name: "ports only partly covered",
+			rules: []Rule{
+				{Action: ActionDeny, Protocol: ProtocolTCP, DstPorts: []PortRange{{1, 1023}}},
+				{Action: ActionAllow, Protocol: ProtocolTCP, DstPorts: []PortRange{{1000, 2000}}},
+			},
+		},
+	}
+
+	for _, pair := range testpairs {
+		p := &Policy{Rules: pair.rules, Default: pair.deflt}
+		actual, err := p.Analyze()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.EqualFunc(actual, pair.expected, func(a, b Finding) bool {
+			return a.Rule == b.Rule && a.Kind == b.Kind && slices.Equal(a.By, b.By)
+		}) {
+			t.Errorf("%s: expected %v, got %v", pair.name, pair.expected, actual)
+		}
+	}
+}
+
+func TestPortsCover(t *testing.T) {
+	testpairs := []struct {
+		a, b     []PortRange
+		expected bool
+	}{
+		{a: nil, b: []PortRange{{80, 80}}, expected: true},
+		{a: []PortRange{{80, 80}}, b: nil},
+		{a: []PortRange{{0, 100}, {101, 65535}}, b: nil, expected: true},
+		{a: []PortRange{{1, 10}, {11, 20}}, b: []PortRange{{5, 15}}, expected: true},
+		{a: []PortRange{{1, 10}, {12, 20}}, b: []PortRange{{5, 15}}},
+	}
+
+	for _, pair := range testpairs {
+		if actual := portsCover(pair.a, pair.b); actual != pair.expected {
+			t.Errorf("%v covers %v: expected %v, got %v", pair.a, pair.b, pair.expected, actual)
+		}
+	}
+}
+
+func TestACLStrings(t *testing.T) {
+	testpairs := []struct {
+		actual   string
+		expected string
+	}{
+		{actual: ActionAllow.String(), expected: "allow"},
+		{actual: ActionDeny.String(), expected: "deny"},
+		{actual: ProtocolAny.String(), expected: "any"},
+		{actual: ProtocolICMPv6.String(), expected: "icmpv6"},
+		{actual: Protocol(47).String(), expected: "47"},
+		{actual: PortRange{80, 80}.String(), expected: "80"},
+		{actual: PortRange{8000, 8080}.String(), expected: "8000-8080"},
+		{actual: Finding{Rule: 2, Kind: FindingShadowed, By: []int{0, 1}}.String(), expected: "rule 2 is shadowed by rules [0 1]"},
+		{actual: Finding{Rule: 3, Kind: FindingRedundant}.String(), expected: "rule 3 is redundant: it repeats the default action"},
+	}
+
+	for _, pair := range testpairs {
+		if pair.actual != pair.expected {
+			t.Errorf("expected %q, got %q", pair.expected, pair.actual)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ruleVariant is a Rule narrowed to one address family, as firewalls
+// cannot mix families within a rule. A family of 0 means the rule
+// matches no addresses and so applies to both families.
+type ruleVariant struct {
+	*Rule
+	family   int
+	src, dst []netip.Prefix
+}
+
+// variants splits r by address family. Families for which r cannot
+// match any packet are left out.
+func variants(r *Rule) []ruleVariant {
+	if len(r.Src) == 0 && len(r.Dst) == 0 {
+		return []ruleVariant{{Rule: r}}
+	}
+	var out []ruleVariant
+	for _, family := range []int{4, 6} {
+		if (family == 4 && r.Protocol == ProtocolICMPv6) || (family == 6 && r.Protocol == ProtocolICMP) {
+			continue
+		}
+		src, dst := filterFamily(r.Src, family), filterFamily(r.Dst, family)
+		if (len(r.Src) > 0 && len(src) == 0) || (len(r.Dst) > 0 && len(dst) == 0) {
+			continue
+		}
+		out = append(out, ruleVariant{Rule: r, family: family, src: src, dst: dst})
+	}
+	return out
+}
+
+// filterFamily returns the prefixes of ps belonging to family.
+func filterFamily(ps []netip.Prefix, family int) []netip.Prefix {
+	var out []netip.Prefix
+	for _, p := range ps {
+		if p.Addr().Is4() == (family == 4) {
+			out = append(out, p.Masked())
+		}
+	}
+	return out
+}
+
+// comment sanitizes a rule name for use as a quoted comment.
+func comment(name string) string {
+	return strings.NewReplacer(`"`, "'", "\n", " ", "\\", "/").Replace(name)
+}
+
+// maxNameLen is the longest chain name iptables accepts.
+const maxNameLen = 28
+
+// checkName returns an error unless name, a table or chain name as
+// described by kind, is 1 to maxNameLen letters, digits, underscores,
+// dots or hyphens, and so cannot alter the ruleset it is written into.
+func checkName(kind, name string) error {
+	if name == "" || len(name) > maxNameLen {
+		return fmt.Errorf("%s name %q must be 1 to %d characters", kind, name, maxNameLen)
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("_.-", c)) {
+			return fmt.Errorf("%s name %q contains %q", kind, name, c)
+		}
+	}
+	return nil
+}
+
+// nftHook returns the filter hook chain names and whether it is one,
+// matching input, forward and output in any case.
+func nftHook(chain string) (string, bool) {
+	hook := strings.ToLower(chain)
+	return hook, slices.Contains([]string{"input", "forward", "output"}, hook)
+}
+
+// NFTables renders p as an nftables script defining chain in the inet
+// table table. If chain is input, forward or output, in any case, it is
+// a base chain hooked there with p.Default as its policy; otherwise it
+// is a regular chain ending in an explicit verdict. Table and chain
+// names are restricted to letters, digits, underscores, dots and
+// hyphens.
+func (p *Policy) NFTables(table, chain string) (string, error) {
+	if err := checkName("table", table); err != nil {
+		return "", err
+	}
+	if err := checkName("chain", chain); err != nil {
+		return "", err
+	}
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "table inet %s {\n", table)
+	fmt.Fprintf(&sb, "\tchain %s {\n", chain)
+	hook, base := nftHook(chain)
+	if base {
+		fmt.Fprintf(&sb, "\t\ttype filter hook %s priority 0; policy %s;\n", hook, nftVerdict(p.Default))
+	}
+	for i := range p.Rules {
+		for _, v := range variants(&p.Rules[i]) {
+			sb.WriteString("\t\t")
+			sb.WriteString(nftRule(v))
+			sb.WriteByte('\n')
+		}
+	}
+	if !base {
+		fmt.Fprintf(&sb, "\t\t%s\n", nftVerdict(p.Default))
+	}
+	sb.WriteString("\t}\n}\n")
+	return sb.String(), nil
+}
+
+func nftVerdict(a Action) string {
+	if a == ActionAllow {
+		return "accept"
+	}
+	return "drop"
+}
+
+// nftRule renders one rule variant.
+func nftRule(v ruleVariant) string {
+	var parts []string
+	ip := "ip"
+	if v.family == 6 {
+		ip = "ip6"
+	}
+	if len(v.src) > 0 {
+		parts = append(parts, ip+" saddr "+nftSet(v.src))
+	}
+	if len(v.dst) > 0 {
+		parts = append(parts, ip+" daddr "+nftSet(v.dst))
+	}
+	switch {
+	case len(v.SrcPorts) > 0 || len(v.DstPorts) > 0:
+		if len(v.SrcPorts) > 0 {
+			parts = append(parts, v.Protocol.String()+" sport "+nftSet(v.SrcPorts))
+		}
+		if len(v.DstPorts) > 0 {
+			parts = append(parts, v.Protocol.String()+" dport "+nftSet(v.DstPorts))
+		}
+	case v.Protocol == ProtocolICMPv6:
+		parts = append(parts, "meta l4proto ipv6-icmp")
+	case v.Protocol != ProtocolAny:
+		parts = append(parts, "meta l4proto "+v.Protocol.String())
+	}
+	parts = append(parts, nftVerdict(v.Action))
+	if v.Name != "" {
+		parts = append(parts, `comment "`+comment(v.Name)+`"`)
+	}
+	return strings.Join(parts, " ")
+}
+
+// nftSet renders xs as a single element or an anonymous set.
+func nftSet[T fmt.Stringer](xs []T) string {
+	if len(xs) == 1 {
+		return xs[0].String()
+	}
+	elems := make([]string, len(xs))
+	for i, x := range xs {
+		elems[i] = x.String()
+	}
+	return "{ " + strings.Join(elems, ", ") + " }"
+}
+
+// multiportMax is the number of ports the iptables multiport match
+// accepts, a range counting as two.
+const multiportMax = 15
+
+// IPTables renders p as iptables-restore input for the filter table,
+// or as ip6tables-restore input if ipv6 is set. Rules restricted to
+// the other family are left out. If chain is INPUT, FORWARD or OUTPUT,
+// in upper case, its policy is p.Default; otherwise it is a
+// user-defined chain ending in an explicit verdict. Chain names are
+// restricted as for NFTables.
+func (p *Policy) IPTables(chain string, ipv6 bool) (string, error) {
+	if err := checkName("chain", chain); err != nil {
+		return "", err
+	}
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+	family := 4
+	if ipv6 {
+		family = 6
+	}
+	base := slices.Contains([]string{"INPUT", "FORWARD", "OUTPUT"}, chain)
+	var sb strings.Builder
+	sb.WriteString("*filter\n")
+	if base {
+		fmt.Fprintf(&sb, ":%s %s [0:0]\n", chain, iptVerdict(p.Default))
+	} else {
+		fmt.Fprintf(&sb, ":%s - [0:0]\n", chain)
+	}
+	for i := range p.Rules {
+		for _, v := range variants(&p.Rules[i]) {
+			if v.family != 0 && v.family != family {
+				continue
+			}
+			for _, line := range iptRules(v, ipv6) {
+				fmt.Fprintf(&sb, "-A %s %s\n", chain, line)
+			}
+		}
+	}
+	if !base {
+		fmt.Fprintf(&sb, "-A %s -j %s\n", chain, iptVerdict(p.Default))
+	}
+	sb.WriteString("COMMIT\n")
+	return sb.String(), nil
+}
+
+func iptVerdict(a Action) string {
+	if a == ActionAllow {
+		return "ACCEPT"
+	}
+	return "DROP"
+}
+
+// iptRules renders one rule variant, splitting it into several rules
+// if it has more ports than the multiport match accepts.
+func iptRules(v ruleVariant, ipv6 bool) []string {
+	var head []string
+	if len(v.src) > 0 {
+		head = append(head, "-s "+iptList(v.src))
+	}
+	if len(v.dst) > 0 {
+		head = append(head, "-d "+iptList(v.dst))
+	}
+	switch v.Protocol {
+	case ProtocolAny:
+	case ProtocolICMP:
+		if ipv6 {
+			return nil
+		}
+		head = append(head, "-p icmp")
+	case ProtocolICMPv6:
+		if !ipv6 {
+			return nil
+		}
+		head = append(head, "-p ipv6-icmp")
+	default:
+		head = append(head, "-p "+v.Protocol.String())
+	}
+
+	var tail []string
+	if v.Name != "" {
+		tail = append(tail, `-m comment --comment "`+comment(v.Name)+`"`)
+	}
+	tail = append(tail, "-j "+iptVerdict(v.Action))
+
+	var out []string
+	for _, sports := range portChunks(v.SrcPorts) {
+		for _, dports := range portChunks(v.DstPorts) {
+			parts := slices.Concat(head, iptPorts(sports, dports), tail)
+			out = append(out, strings.Join(parts, " "))
+		}
+	}
+	return out
+}
+
+// iptList renders prefixes as a comma-separated list.
+func iptList(ps []netip.Prefix) string {
+	elems := make([]string, len(ps))
+	for i, p := range ps {
+		elems[i] = p.String()
+	}
+	return strings.Join(elems, ",")
+}
+
+// portChunks divides rs into groups small enough for the multiport
+// match. An empty list yields a single empty group.
+func portChunks(rs []PortRange) [][]PortRange {
+	if len(rs) == 0 {
+		return [][]PortRange{nil}
+	}
+	var (
+		out    [][]PortRange
+		cur    []PortRange
+		weight int
+	)
+	for _, r := range rs {
+		w := 1
+		if r.From != r.To {
+			w = 2
+		}
+		if weight+w > multiportMax {
+			out = append(out, cur)
+			cur, weight = nil, 0
+		}
+		cur = append(cur, r)
+		weight += w
+	}
+	return append(out, cur)
+}
+
+// iptPorts renders port matches, using the multiport match for lists
+// of more than one range.
+func iptPorts(sports, dports []PortRange) []string {
+	var out []string
+	for _, m := range []struct {
+		flag  string
+		ports []PortRange
+	}{{"sport", sports}, {"dport", dports}} {
+		switch len(m.ports) {
+		case 0:
+		case 1:
+			out = append(out, "--"+m.flag+" "+iptPort(m.ports[0]))
+		default:
+			out = append(out, "-m multiport --"+m.flag+"s "+iptPortList(m.ports))
+		}
+	}
+	return out
+}
+
+func iptPort(r PortRange) string {
+	if r.From == r.To {
+		return strconv.Itoa(int(r.From))
+	}
+	return strconv.Itoa(int(r.From)) + ":" + strconv.Itoa(int(r.To))
+}
+
+func iptPortList(rs []PortRange) string {
+	elems := make([]string, len(rs))
+	for i, r := range rs {
+		elems[i] = iptPort(r)
+	}
+	return strings.Join(elems, ",")
+}
@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPolicyNFTables(t *testing.T) {
+	actual, err := samplePolicy(t).NFTables("filter", "input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `table inet filter {
+	chain input {
+		type filter hook input priority 0; policy drop;
+		ip saddr 10.0.0.0/8 drop comment "block bogons"
+		ip6 saddr fc00::/7 drop comment "block bogons"
+		ip saddr 192.0.2.0/24 tcp dport 22 accept comment "ssh from office"
+		ip daddr 198.51.100.0/24 tcp dport { 80, 443 } accept comment "web"
+		ip6 daddr 2001:db8::/32 tcp dport { 80, 443 } accept comment "web"
+		meta l4proto icmp accept comment "ping"
+	}
+}
+`
+	if actual != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+	}
+}
+
+func TestPolicyNFTablesRegularChain(t *testing.T) {
+	p := &Policy{
+		Default: ActionAllow,
+		Rules: []Rule{
+			{Action: ActionDeny, Src: parsePrefixes(t, "192.0.2.0/24"), Dst: parsePrefixes(t, "2001:db8::/32")},
+			{Name: `say "hi"`, Action: ActionDeny, Protocol: ProtocolUDP, SrcPorts: []PortRange{{53, 53}}, DstPorts: []PortRange{{1024, 65535}}},
+			{Action: ActionDeny, Dst: parsePrefixes(t, "2001:db8::/32"), Protocol: ProtocolICMPv6},
+			{Action: ActionDeny, Src: parsePrefixes(t, "192.0.2.0/24"), Protocol: ProtocolICMPv6},
+			{Action: ActionDeny, Protocol: Protocol(47)},
+		},
+	}
+	actual, err := p.NFTables("fw", "edge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first and fourth rules cannot match any packet.
+	expected := `table inet fw {
+	chain edge {
+		udp sport 53 udp dport 1024-65535 drop comment "say 'hi'"
+		ip6 daddr 2001:db8::/32 meta l4proto ipv6-icmp drop
+		meta l4proto 47 drop
+		accept
+	}
+}
+`
+	if actual != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+	}
+}
+
+func TestPolicyIPTables(t *testing.T) {
+	actual, err := samplePolicy(t).IPTables("INPUT", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `*filter
+:INPUT DROP [0:0]
+-A INPUT -s 10.0.0.0/8 -m comment --comment "block bogons" -j DROP
+-A INPUT -s 192.0.2.0/24 -p tcp --dport 22 -m comment --comment "ssh from office" -j ACCEPT
+-A INPUT -d 198.51.100.0/24 -p tcp -m multiport --dports 80,443 -m comment --comment "web" -j ACCEPT
+-A INPUT -p icmp -m comment --comment "ping" -j ACCEPT
+COMMIT
+`
+	if actual != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+	}
+
+	actual, err = samplePolicy(t).IPTables("edge", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `*filter
+:edge - [0:0]
+-A edge -s fc00::/7 -m comment --comment "block bogons" -j DROP
+-A edge -d 2001:db8::/32 -p tcp -m multiport --dports 80,443 -m comment --comment "web" -j ACCEPT
+-A edge -j DROP
+COMMIT
+`
+	if actual != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+	}
+}
+
+func TestPolicyIPTablesMultiport(t *testing.T) {
+	var ports []PortRange
+	for i := range 10 {
+		ports = append(ports, PortRange{uint16(1000 + 10*i), uint16(1000 + 10*i + 5)})
+	}
+	p := &Policy{Rules: []Rule{{Action: ActionAllow, Protocol: ProtocolTCP, SrcPorts: []PortRange{{53, 53}, {123, 123}}, DstPorts: ports}}}
+	actual, err := p.IPTables("FORWARD", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `*filter
+:FORWARD DROP [0:0]
+-A FORWARD -p tcp -m multiport --sports 53,123 -m multiport --dports 1000:1005,1010:1015,1020:1025,1030:1035,1040:1045,1050:1055,1060:1065 -j ACCEPT
+-A FORWARD -p tcp -m multiport --sports 53,123 -m multiport --dports 1070:1075,1080:1085,1090:1095 -j ACCEPT
+COMMIT
+`
+	if actual != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+	}
+}
+
+func TestPolicyExportInvalid(t *testing.T) {
+	p := &Policy{Rules: []Rule{{Name: "bad", Protocol: ProtocolICMP, DstPorts: []PortRange{{80, 80}}}}}
+	if _, err := p.NFTables("filter", "input"); err == nil || !strings.Contains(err.Error(), "rule 0 (bad)") {
+		t.Errorf("expected error naming rule 0, got %v", err)
+	}
+	if _, err := p.IPTables("INPUT", false); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestPolicyExportNames(t *testing.T) {
+	p := &Policy{Default: ActionDeny}
+	for _, name := range []string{
+		"",
+		"in put",
+		"input\n-A INPUT -j ACCEPT",
+		"input { policy accept; }",
+		"x;",
+		`"quoted"`,
+		strings.Repeat("x", 29),
+	} {
+		if _, err := p.NFTables("filter", name); err == nil {
+			t.Errorf("%q: expected error", name)
+		}
+		if _, err := p.NFTables(name, "input"); err == nil {
+			t.Errorf("%q: expected error", name)
+		}
+		if _, err := p.IPTables(name, false); err == nil {
+			t.Errorf("%q: expected error", name)
+		}
+	}
+	for _, name := range []string{"edge-v4.1_a", strings.Repeat("x", 28)} {
+		if _, err := p.IPTables(name, false); err != nil {
+			t.Errorf("%q: %v", name, err)
+		}
+	}
+}
+
+func TestPolicyIPTablesChainCase(t *testing.T) {
+	// Only the upper case built-in chains take a policy in iptables,
+	// while nftables hooks are matched in any case.
+	p := &Policy{Default: ActionDeny}
+	actual, err := p.IPTables("input", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "*filter\n:input - [0:0]\n-A input -j DROP\nCOMMIT\n"
+	if actual != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+	}
+	actual, err = p.NFTables("filter", "INPUT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(actual, "type filter hook input priority 0; policy drop;") {
+		t.Errorf("expected an input hook, got\n%s", actual)
+	}
+}
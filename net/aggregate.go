@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+)
+
+// Aggregate collapses prefixes, which may overlap, abut and mix
+// address families, into the smallest list of prefixes covering
+// exactly the same addresses. The result is sorted, with IPv4 before
+// IPv6.
+func Aggregate(prefixes ...netip.Prefix) ([]netip.Prefix, error) {
+	s, err := NewIPSet(prefixes...)
+	if err != nil {
+		return nil, err
+	}
+	return s.Prefixes(), nil
+}
+
+// Summary is the result of a lossy summarization.
+type Summary struct {
+	Prefixes []netip.Prefix // the summarized prefixes
+	Extra    *big.Int       // addresses covered by Prefixes but not by the input
+}
+
+// Summarize aggregates prefixes like Aggregate, but first widens any
+// IPv4 prefix longer than maxBits4, and any IPv6 prefix longer than
+// maxBits6, to that length. The number of addresses the summary covers
+// beyond the input is reported in Extra.
+func Summarize(maxBits4, maxBits6 int, prefixes ...netip.Prefix) (*Summary, error) {
+	if maxBits4 < 0 || maxBits4 > 32 {
+		return nil, fmt.Errorf("%d is not a valid IPv4 prefix length", maxBits4)
+	}
+	if maxBits6 < 0 || maxBits6 > 128 {
+		return nil, fmt.Errorf("%d is not a valid IPv6 prefix length", maxBits6)
+	}
+	exact, err := NewIPSet(prefixes...)
+	if err != nil {
+		return nil, err
+	}
+
+	widened := make([]netip.Prefix, len(prefixes))
+	for i, p := range prefixes {
+		maxBits := maxBits6
+		if p.Addr().Is4() {
+			maxBits = maxBits4
+		}
+		if p.Bits() > maxBits {
+			p = netip.PrefixFrom(p.Addr(), maxBits)
+		}
+		widened[i] = p
+	}
+	lossy, err := NewIPSet(widened...)
+	if err != nil {
+		return nil, err
+	}
+
+	extra := lossy.Size()
+	return &Summary{
+		Prefixes: lossy.Prefixes(),
+		Extra:    extra.Sub(extra, exact.Size()),
+	}, nil
+}
@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func parsePrefixes(t *testing.T, xs ...string) []netip.Prefix {
+	t.Helper()
+	ps := make([]netip.Prefix, len(xs))
+	for i, s := range xs {
+		ps[i] = netip.MustParsePrefix(s)
+	}
+	return ps
+}
+
+func joinPrefixes(ps []netip.Prefix) string {
+	xs := make([]string, len(ps))
+	for i, p := range ps {
+		xs[i] = p.String()
+	}
+	return strings.Join(xs, " ")
+}
+
+func TestAggregate(t *testing.T) {
+	testpairs := []struct {
+		input    []string
+		expected string
+	}{
+		{input: nil, expected: ""},
+		{input: []string{"192.0.2.0/25", "192.0.2.128/25"}, expected: "192.0.2.0/24"},
+		{input: []string{"192.0.2.0/24", "192.0.2.64/26", "192.0.2.1/32"}, expected: "192.0.2.0/24"},
+		{input: []string{"192.0.2.0/26", "192.0.2.64/26", "192.0.2.128/26"}, expected: "192.0.2.0/25 192.0.2.128/26"},
+		{input: []string{"10.0.1.0/24", "10.0.0.0/24", "10.0.3.0/24", "10.0.2.0/24"}, expected: "10.0.0.0/22"},
+		{input: []string{"10.0.1.0/24", "10.0.2.0/24"}, expected: "10.0.1.0/24 10.0.2.0/24"},
+		{input: []string{"2001:db8::/33", "10.0.0.0/9", "2001:db8:8000::/33", "10.128.0.0/9"}, expected: "10.0.0.0/8 2001:db8::/32"},
+		{input: []string{"10.1.2.3/8"}, expected: "10.0.0.0/8"},
+	}
+
+	for _, pair := range testpairs {
+		actual, err := Aggregate(parsePrefixes(t, pair.input...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if joinPrefixes(actual) != pair.expected {
+			t.Errorf("%v: expected %v, got %v", pair.input, pair.expected, actual)
+		}
+	}
+
+	if _, err := Aggregate(netip.Prefix{}); err == nil {
+		t.Error("expected error for invalid prefix")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	testcases := []struct {
+		input    []string
+		maxBits4 int
+		maxBits6 int
+		expected string
+		extra    string
+	}{
+		{
+			input:    []string{"10.0.0.0/24", "10.0.1.0/24"},
+			maxBits4: 32, maxBits6: 128,
+			expected: "10.0.0.0/23", extra: "0",
+		},
+		{
+			input:    []string{"10.0.0.0/24", "10.0.2.0/24"},
+			maxBits4: 22, maxBits6: 128,
+			expected: "10.0.0.0/22", extra: "512",
+		},
+		{
+			input:    []string{"10.0.0.1/32", "10.0.0.200/32", "10.0.1.5/32"},
+			maxBits4: 24, maxBits6: 128,
+			expected: "10.0.0.0/23", extra: "509",
+		},
+		{
+			input:    []string{"2001:db8::1/128", "2001:db8:0:1::/64", "192.0.2.1/32"},
+			maxBits4: 24, maxBits6: 64,
+			expected: "192.0.2.0/24 2001:db8::/63", extra: "18446744073709551870",
+		},
+	}
+
+	for _, tc := range testcases {
+		actual, err := Summarize(tc.maxBits4, tc.maxBits6, parsePrefixes(t, tc.input...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if joinPrefixes(actual.Prefixes) != tc.expected {
+			t.Errorf("%v: expected %v, got %v", tc.input, tc.expected, actual.Prefixes)
+		}
+		if actual.Extra.String() != tc.extra {
+			t.Errorf("%v: expected %v extra addresses, got %v", tc.input, tc.extra, actual.Extra)
+		}
+	}
+}
+
+func TestSummarizeErrors(t *testing.T) {
+	testcases := []struct {
+		maxBits4 int
+		maxBits6 int
+		input    []netip.Prefix
+	}{
+		{maxBits4: 33, maxBits6: 64},
+		{maxBits4: 24, maxBits6: 129},
+		{maxBits4: -1, maxBits6: 64},
+		{maxBits4: 24, maxBits6: 64, input: []netip.Prefix{{}}},
+	}
+
+	for _, tc := range testcases {
+		actual, err := Summarize(tc.maxBits4, tc.maxBits6, tc.input...)
+		if err == nil {
+			t.Errorf("%d/%d: expected error, got %v", tc.maxBits4, tc.maxBits6, actual)
+		}
+	}
+}
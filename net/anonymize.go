@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// An Anonymizer pseudonymizes IP addresses. The result belongs to the
+// same family as the input, and any zone is kept.
+type Anonymizer interface {
+	Anonymize(addr netip.Addr) (netip.Addr, error)
+}
+
+// Truncation anonymizes addresses by clearing all but their first
+// Bits4 (IPv4) or Bits6 (IPv6) bits, e.g. 24 and 48 for the common
+// practice of dropping the last IPv4 octet and the last 80 IPv6 bits.
+type Truncation struct {
+	Bits4 int // the number of IPv4 bits kept
+	Bits6 int // the number of IPv6 bits kept
+}
+
+// NewTruncation returns a Truncation keeping bits4 IPv4 bits and bits6
+// IPv6 bits.
+func NewTruncation(bits4, bits6 int) (*Truncation, error) {
+	t := &Truncation{Bits4: bits4, Bits6: bits6}
+	if err := t.check(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *Truncation) check() error {
+	if t.Bits4 < 0 || t.Bits4 > 32 {
+		return fmt.Errorf("invalid IPv4 prefix length %d", t.Bits4)
+	}
+	if t.Bits6 < 0 || t.Bits6 > 128 {
+		return fmt.Errorf("invalid IPv6 prefix length %d", t.Bits6)
+	}
+	return nil
+}
+
+// Anonymize returns addr truncated to the configured prefix length.
+func (t *Truncation) Anonymize(addr netip.Addr) (netip.Addr, error) {
+	if !addr.IsValid() {
+		return netip.Addr{}, errors.New("invalid netip.Addr")
+	}
+	if err := t.check(); err != nil {
+		return netip.Addr{}, err
+	}
+	bits := t.Bits6
+	if addr.Is4() {
+		bits = t.Bits4
+	}
+	p, err := addr.WithZone("").Prefix(bits)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return p.Addr().WithZone(addr.Zone()), nil
+}
+
+// HMACAnonymizer anonymizes addresses by replacing them with the
+// leading bytes of their HMAC-SHA256 under a secret key. The mapping is
+// consistent for a given key but, unlike CryptoPAn, does not preserve
+// prefixes.
+type HMACAnonymizer struct {
+	key []byte
+}
+
+// NewHMACAnonymizer returns an HMACAnonymizer using key, which must
+// not be empty.
+func NewHMACAnonymizer(key []byte) (*HMACAnonymizer, error) {
+	if len(key) == 0 {
+		return nil, errors.New("empty HMAC key")
+	}
+	return &HMACAnonymizer{key: append([]byte(nil), key...)}, nil
+}
+
+// Anonymize returns the pseudonym of addr.
+func (h *HMACAnonymizer) Anonymize(addr netip.Addr) (netip.Addr, error) {
+	if !addr.IsValid() {
+		return netip.Addr{}, errors.New("invalid netip.Addr")
+	}
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write(addr.WithZone("").AsSlice())
+	sum := mac.Sum(nil)
+	if addr.Is4() {
+		return netip.AddrFrom4([4]byte(sum[:4])), nil
+	}
+	return netip.AddrFrom16([16]byte(sum[:16])).WithZone(addr.Zone()), nil
+}
+
+// CryptoPAnKeySize is the size of a CryptoPAn key.
+const CryptoPAnKeySize = 32
+
+// CryptoPAn is the prefix-preserving anonymization scheme of Xu, Fan,
+// Ammar and Moon: two addresses sharing their first n bits map to
+// addresses sharing their first n bits. IPv4 results match the
+// reference implementation; IPv6 addresses are handled by the same
+// construction over 128 bits.
+type CryptoPAn struct {
+	block cipher.Block
+	pad   [aes.BlockSize]byte
+}
+
+// NewCryptoPAn returns a CryptoPAn using key, whose first 16 bytes
+// are the AES key and whose last 16 bytes are encrypted to form the
+// pad.
+func NewCryptoPAn(key []byte) (*CryptoPAn, error) {
+	if len(key) != CryptoPAnKeySize {
+		return nil, fmt.Errorf("CryptoPAn key must be %d bytes, not %d", CryptoPAnKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key[:16])
+	if err != nil {
+		return nil, err
+	}
+	c := &CryptoPAn{block: block}
+	block.Encrypt(c.pad[:], key[16:])
+	return c, nil
+}
+
+// Anonymize returns the prefix-preserving pseudonym of addr.
+func (c *CryptoPAn) Anonymize(addr netip.Addr) (netip.Addr, error) {
+	if !addr.IsValid() {
+		return netip.Addr{}, errors.New("invalid netip.Addr")
+	}
+	width := addr.BitLen()
+	orig := u128(addr)
+	var pad uint128
+	if addr.Is4() {
+		pad = uint128{0, uint64(binary.BigEndian.Uint32(c.pad[:4]))}
+	} else {
+		pad = uint128{binary.BigEndian.Uint64(c.pad[:8]), binary.BigEndian.Uint64(c.pad[8:])}
+	}
+
+	// Bit i of the result flips bit i of addr according to a
+	// pseudorandom function of the i bits preceding it.
+	var flips uint128
+	in, out := c.pad, [aes.BlockSize]byte{}
+	for i := range width {
+		keep := hostMask(width - i).not().and(hostMask(width))
+		x := orig.and(keep).or(pad.and(keep.not()))
+		if addr.Is4() {
+			binary.BigEndian.PutUint32(in[:4], uint32(x.lo))
+		} else {
+			binary.BigEndian.PutUint64(in[:8], x.hi)
+			binary.BigEndian.PutUint64(in[8:], x.lo)
+		}
+		c.block.Encrypt(out[:], in[:])
+		flips = flips.or(uint128{0, uint64(out[0] >> 7)}.lsh(uint(width - 1 - i)))
+	}
+	return orig.xor(flips).addr(addr.Is4()).WithZone(addr.Zone()), nil
+}
@@ -0,0 +1,177 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"net/netip"
+	"testing"
+)
+
+// cryptoPAnKey is the key used by the reference implementation's
+// sample.
+var cryptoPAnKey = []byte{
+	21, 34, 23, 141, 51, 164, 207, 128, 19, 10, 91, 22, 73, 144, 125, 16,
+	216, 152, 143, 131, 121, 121, 101, 39, 98, 87, 76, 45, 42, 132, 34, 2,
+}
+
+func TestCryptoPAn(t *testing.T) {
+	c, err := NewCryptoPAn(cryptoPAnKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The reference implementation's sample trace.
+	testpairs := []struct {
+		input    string
+		expected string
+	}{
+		{input: "128.11.68.132", expected: "135.242.180.132"},
+		{input: "129.118.74.4", expected: "134.136.186.123"},
+		{input: "130.132.252.244", expected: "133.68.164.234"},
+		{input: "141.223.7.43", expected: "141.167.8.160"},
+		{input: "141.233.145.108", expected: "141.129.237.235"},
+		{input: "152.163.225.39", expected: "151.140.114.167"},
+		{input: "156.29.3.236", expected: "147.225.12.42"},
+		{input: "165.247.96.84", expected: "162.9.99.234"},
+		{input: "166.107.77.190", expected: "160.132.178.185"},
+		{input: "192.102.249.13", expected: "252.138.62.131"},
+	}
+
+	for _, pair := range testpairs {
+		actual, err := c.Anonymize(netip.MustParseAddr(pair.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.String() != pair.expected {
+			t.Errorf("%s: expected %s, got %v", pair.input, pair.expected, actual)
+		}
+	}
+}
+
+func TestCryptoPAnPrefixPreserving(t *testing.T) {
+	c, err := NewCryptoPAn(cryptoPAnKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testpairs := []struct {
+		a string
+		b string
+	}{
+		{a: "10.1.2.3", b: "10.1.2.4"},
+		{a: "10.1.2.3", b: "10.200.0.1"},
+		{a: "192.0.2.1", b: "64.0.0.1"},
+		{a: "2001:db8::1", b: "2001:db8::2"},
+		{a: "2001:db8:1::1", b: "2001:db8:ffff::1"},
+		{a: "2001:db8::1", b: "fe80::1"},
+	}
+
+	for _, pair := range testpairs {
+		a, b := netip.MustParseAddr(pair.a), netip.MustParseAddr(pair.b)
+		x, err := c.Anonymize(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		y, err := c.Anonymize(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x.Is4() != a.Is4() {
+			t.Errorf("%v: family changed to %v", a, x)
+		}
+		expected := commonBits(u128(a), u128(b), a.BitLen(), a.BitLen())
+		if actual := commonBits(u128(x), u128(y), x.BitLen(), x.BitLen()); actual != expected {
+			t.Errorf("%v %v -> %v %v: expected %d common bits, got %d", a, b, x, y, expected, actual)
+		}
+	}
+
+	if actual, err := c.Anonymize(netip.MustParseAddr("fe80::1%eth0")); err != nil || actual.Zone() != "eth0" {
+		t.Errorf("expected zone eth0, got %v (%v)", actual, err)
+	}
+	if _, err := NewCryptoPAn(cryptoPAnKey[:16]); err == nil {
+		t.Error("expected error for short key")
+	}
+	if _, err := c.Anonymize(netip.Addr{}); err == nil {
+		t.Error("expected error for invalid address")
+	}
+}
+
+func TestTruncation(t *testing.T) {
+	tr, err := NewTruncation(24, 48)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testpairs := []struct {
+		input    string
+		expected string
+	}{
+		{input: "192.0.2.77", expected: "192.0.2.0"},
+		{input: "2001:db8:1:2:3:4:5:6", expected: "2001:db8:1::"},
+		{input: "::ffff:192.0.2.77", expected: "::"},
+		{input: "fe80::1%eth0", expected: "fe80::%eth0"},
+	}
+
+	for _, pair := range testpairs {
+		actual, err := tr.Anonymize(netip.MustParseAddr(pair.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.String() != pair.expected {
+			t.Errorf("%s: expected %s, got %v", pair.input, pair.expected, actual)
+		}
+	}
+
+	for _, bits := range [][2]int{{-1, 48}, {33, 48}, {24, -1}, {24, 129}} {
+		if _, err := NewTruncation(bits[0], bits[1]); err == nil {
+			t.Errorf("%v: expected error", bits)
+		}
+	}
+	if _, err := (&Truncation{Bits4: 40}).Anonymize(netip.MustParseAddr("192.0.2.1")); err == nil {
+		t.Error("expected error for invalid prefix length")
+	}
+}
+
+func TestHMACAnonymizer(t *testing.T) {
+	h, err := NewHMACAnonymizer([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"192.0.2.1", "2001:db8::1"} {
+		addr := netip.MustParseAddr(s)
+		x, err := h.Anonymize(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		y, _ := h.Anonymize(addr)
+		if x != y {
+			t.Errorf("%v: inconsistent pseudonyms %v and %v", addr, x, y)
+		}
+		if x == addr || x.Is4() != addr.Is4() {
+			t.Errorf("%v: unexpected pseudonym %v", addr, x)
+		}
+	}
+
+	other, err := NewHMACAnonymizer([]byte("other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := netip.MustParseAddr("192.0.2.1")
+	x, _ := h.Anonymize(addr)
+	y, _ := other.Anonymize(addr)
+	if x == y {
+		t.Errorf("%v: keys yield the same pseudonym %v", addr, x)
+	}
+	if _, err := NewHMACAnonymizer(nil); err == nil {
+		t.Error("expected error for empty key")
+	}
+}
+
+func TestAnonymizer(t *testing.T) {
+	c, _ := NewCryptoPAn(cryptoPAnKey)
+	h, _ := NewHMACAnonymizer([]byte("secret"))
+	tr, _ := NewTruncation(24, 48)
+	for _, a := range []Anonymizer{c, h, tr} {
+		if _, err := a.Anonymize(netip.MustParseAddr("192.0.2.1")); err != nil {
+			t.Errorf("%T: %v", a, err)
+		}
+	}
+}
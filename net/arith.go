@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/netip"
+)
+
+// ErrAddrOverflow is returned when address arithmetic leaves the
+// address space of the operand's family.
+var ErrAddrOverflow = errors.New("address arithmetic overflow")
+
+// AddrToBig returns the integer value of addr. IPv4-mapped IPv6
+// addresses are treated as IPv6.
+func AddrToBig(addr netip.Addr) (*big.Int, error) {
+	if !addr.IsValid() {
+		return nil, errors.New("invalid netip.Addr")
+	}
+	return u128(addr).big(), nil
+}
+
+// AddrFromBigv4 returns the IPv4 address whose integer value is n.
+func AddrFromBigv4(n *big.Int) (netip.Addr, error) {
+	if n == nil {
+		return netip.Addr{}, errors.New("nil big.Int")
+	}
+	if n.Sign() < 0 || n.BitLen() > 32 {
+		return netip.Addr{}, fmt.Errorf("%s is out of range for IPv4", n)
+	}
+	return AddrFromUint32(uint32(n.Uint64())), nil
+}
+
+// AddrFromBigv6 returns the IPv6 address whose integer value is n.
+func AddrFromBigv6(n *big.Int) (netip.Addr, error) {
+	if n == nil {
+		return netip.Addr{}, errors.New("nil big.Int")
+	}
+	u, ok := u128FromBig(n)
+	if !ok {
+		return netip.Addr{}, fmt.Errorf("%s is out of range for IPv6", n)
+	}
+	return u.addr(false), nil
+}
+
+// AddrToUint32 returns the integer value of the IPv4 address addr.
+func AddrToUint32(addr netip.Addr) (uint32, error) {
+	if !addr.Is4() {
+		return 0, fmt.Errorf("%s is not an IPv4 address", addr)
+	}
+	return uint32(u128(addr).lo), nil
+}
+
+// AddrFromUint32 returns the IPv4 address whose integer value is n.
+func AddrFromUint32(n uint32) netip.Addr {
+	return uint128{0, uint64(n)}.addr(true)
+}
+
+// AddOffset returns addr advanced by n, which may be negative. It
+// returns ErrAddrOverflow if the result falls outside the address
+// family of addr. The zone of addr, if any, is kept.
+func AddOffset(addr netip.Addr, n *big.Int) (netip.Addr, error) {
+	if !addr.IsValid() {
+		return netip.Addr{}, errors.New("invalid netip.Addr")
+	}
+	if n == nil {
+		return netip.Addr{}, errors.New("nil big.Int")
+	}
+	sum := new(big.Int).Add(u128(addr).big(), n)
+	if sum.Sign() < 0 || sum.BitLen() > addr.BitLen() {
+		return netip.Addr{}, fmt.Errorf("%s%+d: %w", addr, n, ErrAddrOverflow)
+	}
+	u, _ := u128FromBig(sum)
+	return u.addr(addr.Is4()).WithZone(addr.Zone()), nil
+}
+
+// AddOffsetInt is AddOffset for an integer offset.
+func AddOffsetInt(addr netip.Addr, n int64) (netip.Addr, error) {
+	return AddOffset(addr, big.NewInt(n))
+}
+
+// SubOffset returns addr moved back by n, which may be negative. It
+// returns ErrAddrOverflow if the result falls outside the address
+// family of addr.
+func SubOffset(addr netip.Addr, n *big.Int) (netip.Addr, error) {
+	if n == nil {
+		return netip.Addr{}, errors.New("nil big.Int")
+	}
+	return AddOffset(addr, new(big.Int).Neg(n))
+}
+
+// SubOffsetInt is SubOffset for an integer offset.
+func SubOffsetInt(addr netip.Addr, n int64) (netip.Addr, error) {
+	return SubOffset(addr, big.NewInt(n))
+}
+
+// Distance returns to minus from, which is negative when to precedes
+// from. The addresses must belong to the same family. The distance
+// from the first to the last address of a prefix is one less than the
+// count reported by NipsPrefix.
+func Distance(from, to netip.Addr) (*big.Int, error) {
+	if !from.IsValid() || !to.IsValid() {
+		return nil, errors.New("invalid netip.Addr")
+	}
+	if from.Is4() != to.Is4() {
+		return nil, fmt.Errorf("%s and %s belong to different address families", from, to)
+	}
+	return new(big.Int).Sub(u128(to).big(), u128(from).big()), nil
+}
+
+// NthAddr returns the address at index n within p, where index 0 is
+// the first address of p. Negative indexes count back from the end, so
+// -1 is the last address and -2 the one before it. An index outside p
+// yields ErrAddrOverflow.
+func NthAddr(p netip.Prefix, n *big.Int) (netip.Addr, error) {
+	if !p.IsValid() {
+		return netip.Addr{}, errors.New("invalid netip.Prefix")
+	}
+	if n == nil {
+		return netip.Addr{}, errors.New("nil big.Int")
+	}
+	size := new(big.Int).Lsh(big.NewInt(1), uint(p.Addr().BitLen()-p.Bits()))
+	i := new(big.Int).Set(n)
+	if i.Sign() < 0 {
+		i.Add(i, size)
+	}
+	if i.Sign() < 0 || i.Cmp(size) >= 0 {
+		return netip.Addr{}, fmt.Errorf("index %s is outside %s: %w", n, p, ErrAddrOverflow)
+	}
+	return AddOffset(firstAddr(p), i)
+}
+
+// NthAddrInt is NthAddr for an integer index.
+func NthAddrInt(p netip.Prefix, n int64) (netip.Addr, error) {
+	return NthAddr(p, big.NewInt(n))
+}
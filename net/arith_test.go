@@ -0,0 +1,223 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"errors"
+	"math/big"
+	"net/netip"
+	"testing"
+)
+
+func TestAddrBig(t *testing.T) {
+	testpairs := []struct {
+		addr     string
+		expected string
+	}{
+		{addr: "0.0.0.0", expected: "0"},
+		{addr: "10.20.0.1", expected: "169082881"},
+		{addr: "255.255.255.255", expected: "4294967295"},
+		{addr: "::", expected: "0"},
+		{addr: "::ffff:1.2.3.4", expected: "281470698652420"},
+		{addr: "2001:db8::1", expected: "42540766411282592856903984951653826561"},
+		{addr: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", expected: "340282366920938463463374607431768211455"},
+	}
+
+	for _, pair := range testpairs {
+		addr := netip.MustParseAddr(pair.addr)
+		n, err := AddrToBig(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n.String() != pair.expected {
+			t.Errorf("%s: expected %s, got %s", addr, pair.expected, n)
+		}
+		back, err := AddrFromBigv6(n)
+		if addr.Is4() {
+			back, err = AddrFromBigv4(n)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if back != addr {
+			t.Errorf("%s: expected %v, got %v", n, addr, back)
+		}
+	}
+
+	if _, err := AddrToBig(netip.Addr{}); err == nil {
+		t.Error("expected error for invalid address")
+	}
+	tooBig := new(big.Int).Lsh(big.NewInt(1), 128)
+	for _, n := range []*big.Int{nil, big.NewInt(-1), new(big.Int).Lsh(big.NewInt(1), 32), tooBig} {
+		if actual, err := AddrFromBigv4(n); err == nil {
+			t.Errorf("%v: expected error, got %v", n, actual)
+		}
+	}
+	for _, n := range []*big.Int{nil, big.NewInt(-1), tooBig} {
+		if actual, err := AddrFromBigv6(n); err == nil {
+			t.Errorf("%v: expected error, got %v", n, actual)
+		}
+	}
+}
+
+func TestAddrUint32(t *testing.T) {
+	addr := netip.MustParseAddr("192.0.2.1")
+	n, err := AddrToUint32(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0xc0000201 {
+		t.Errorf("expected %d, got %d", uint32(0xc0000201), n)
+	}
+	if back := AddrFromUint32(n); back != addr {
+		t.Errorf("expected %v, got %v", addr, back)
+	}
+	for _, s := range []string{"::1", "::ffff:192.0.2.1"} {
+		if actual, err := AddrToUint32(netip.MustParseAddr(s)); err == nil {
+			t.Errorf("%s: expected error, got %d", s, actual)
+		}
+	}
+}
+
+func TestAddOffset(t *testing.T) {
+	testpairs := []struct {
+		addr     string
+		offset   int64
+		expected string
+	}{
+		{addr: "10.20.0.0", offset: 10, expected: "10.20.0.10"},
+		{addr: "10.20.0.255", offset: 1, expected: "10.20.1.0"},
+		{addr: "10.20.1.0", offset: -1, expected: "10.20.0.255"},
+		{addr: "255.255.255.254", offset: 1, expected: "255.255.255.255"},
+		{addr: "2001:db8::ffff:ffff:ffff:ffff", offset: 1, expected: "2001:db8:0:1::"},
+		{addr: "fe80::1%eth0", offset: 1, expected: "fe80::2%eth0"},
+		{addr: "::ffff:255.255.255.255", offset: 1, expected: "::1:0:0:0"},
+	}
+
+	for _, pair := range testpairs {
+		addr := netip.MustParseAddr(pair.addr)
+		actual, err := AddOffsetInt(addr, pair.offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.String() != pair.expected {
+			t.Errorf("%s%+d: expected %s, got %v", addr, pair.offset, pair.expected, actual)
+		}
+		back, err := SubOffsetInt(actual, pair.offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if back != addr {
+			t.Errorf("%v-%d: expected %v, got %v", actual, pair.offset, addr, back)
+		}
+	}
+}
+
+func TestAddOffsetOverflow(t *testing.T) {
+	testpairs := []struct {
+		addr   string
+		offset *big.Int
+	}{
+		{addr: "255.255.255.255", offset: big.NewInt(1)},
+		{addr: "0.0.0.0", offset: big.NewInt(-1)},
+		{addr: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", offset: big.NewInt(1)},
+		{addr: "::", offset: big.NewInt(-1)},
+		{addr: "::", offset: new(big.Int).Lsh(big.NewInt(1), 128)},
+	}
+
+	for _, pair := range testpairs {
+		actual, err := AddOffset(netip.MustParseAddr(pair.addr), pair.offset)
+		if !errors.Is(err, ErrAddrOverflow) {
+			t.Errorf("%s%+d: expected ErrAddrOverflow, got %v (%v)", pair.addr, pair.offset, actual, err)
+		}
+	}
+	if _, err := AddOffset(netip.MustParseAddr("::"), nil); err == nil {
+		t.Error("expected error for nil offset")
+	}
+	if _, err := SubOffset(netip.MustParseAddr("::"), nil); err == nil {
+		t.Error("expected error for nil offset")
+	}
+}
+
+func TestDistance(t *testing.T) {
+	testpairs := []struct {
+		from     string
+		to       string
+		expected string
+	}{
+		{from: "10.20.0.0", to: "10.20.0.10", expected: "10"},
+		{from: "10.20.0.10", to: "10.20.0.0", expected: "-10"},
+		{from: "0.0.0.0", to: "255.255.255.255", expected: "4294967295"},
+		{from: "::", to: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", expected: "340282366920938463463374607431768211455"},
+		{from: "2001:db8::", to: "2001:db8::", expected: "0"},
+	}
+
+	for _, pair := range testpairs {
+		actual, err := Distance(netip.MustParseAddr(pair.from), netip.MustParseAddr(pair.to))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.String() != pair.expected {
+			t.Errorf("%s..%s: expected %s, got %s", pair.from, pair.to, pair.expected, actual)
+		}
+	}
+
+	if actual, err := Distance(netip.MustParseAddr("1.2.3.4"), netip.MustParseAddr("::ffff:1.2.3.4")); err == nil {
+		t.Errorf("expected error, got %v", actual)
+	}
+}
+
+func TestNthAddr(t *testing.T) {
+	testpairs := []struct {
+		prefix   string
+		n        int64
+		expected string
+	}{
+		{prefix: "10.20.0.0/16", n: 0, expected: "10.20.0.0"},
+		{prefix: "10.20.0.0/16", n: 10, expected: "10.20.0.10"},
+		{prefix: "10.20.0.0/16", n: -1, expected: "10.20.255.255"},
+		{prefix: "10.20.0.0/16", n: -2, expected: "10.20.255.254"},
+		{prefix: "10.20.0.0/16", n: 65535, expected: "10.20.255.255"},
+		{prefix: "10.20.0.0/16", n: -65536, expected: "10.20.0.0"},
+		{prefix: "10.20.1.2/16", n: 1, expected: "10.20.0.1"},
+		{prefix: "0.0.0.0/0", n: -1, expected: "255.255.255.255"},
+		{prefix: "192.0.2.1/32", n: 0, expected: "192.0.2.1"},
+		{prefix: "2001:db8::/64", n: -1, expected: "2001:db8::ffff:ffff:ffff:ffff"},
+		{prefix: "::/0", n: -1, expected: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"},
+	}
+
+	for _, pair := range testpairs {
+		p := netip.MustParsePrefix(pair.prefix)
+		actual, err := NthAddrInt(p, pair.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.String() != pair.expected {
+			t.Errorf("%s[%d]: expected %s, got %v", p, pair.n, pair.expected, actual)
+		}
+	}
+
+	// The last index agrees with the address count.
+	p := netip.MustParsePrefix("2001:db8::/96")
+	n, err := NipsPrefix(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last, err := NthAddr(p, new(big.Int).Sub(n, big.NewInt(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last != lastAddr(p) {
+		t.Errorf("expected %v, got %v", lastAddr(p), last)
+	}
+	if actual, err := NthAddr(p, n); !errors.Is(err, ErrAddrOverflow) {
+		t.Errorf("expected ErrAddrOverflow, got %v (%v)", actual, err)
+	}
+	if actual, err := NthAddr(p, new(big.Int).Neg(n).Sub(new(big.Int).Neg(n), big.NewInt(1))); !errors.Is(err, ErrAddrOverflow) {
+		t.Errorf("expected ErrAddrOverflow, got %v (%v)", actual, err)
+	}
+	if actual, err := NthAddrInt(netip.MustParsePrefix("192.0.2.0/24"), 256); !errors.Is(err, ErrAddrOverflow) {
+		t.Errorf("expected ErrAddrOverflow, got %v (%v)", actual, err)
+	}
+}
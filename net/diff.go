@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// PrefixDiff is the difference in address space between two
+// collections of prefixes.
+type PrefixDiff struct {
+	Added   *ListCoverage // address space only in the after collection
+	Removed *ListCoverage // address space only in the before collection
+}
+
+// DiffPrefixes compares the address space covered by before and after,
+// either of which may hold overlapping, unaligned or unsorted
+// prefixes. Only the addresses covered matter, so rewriting 10.0.0.0/8
+// as two /9s is no change at all. The added and removed space are
+// reported as minimal prefix lists.
+func DiffPrefixes(before, after []netip.Prefix) (*PrefixDiff, error) {
+	oldSet, err := NewIPSet(before...)
+	if err != nil {
+		return nil, err
+	}
+	newSet, err := NewIPSet(after...)
+	if err != nil {
+		return nil, err
+	}
+	added, err := NewListCoverage(newSet.Difference(oldSet).Prefixes()...)
+	if err != nil {
+		return nil, err
+	}
+	removed, err := NewListCoverage(oldSet.Difference(newSet).Prefixes()...)
+	if err != nil {
+		return nil, err
+	}
+	return &PrefixDiff{Added: added, Removed: removed}, nil
+}
+
+// IsEmpty reports whether the two collections cover the same
+// addresses.
+func (d *PrefixDiff) IsEmpty() bool {
+	return len(d.Added.Prefixes) == 0 && len(d.Removed.Prefixes) == 0
+}
+
+// String renders d as text in the style of a unified diff: each added
+// prefix on a line of its own prefixed with +, each removed prefix
+// prefixed with -, and a summary of the address counts.
+func (d *PrefixDiff) String() string {
+	var sb strings.Builder
+	for _, p := range d.Removed.Prefixes {
+		fmt.Fprintf(&sb, "-%s\n", p)
+	}
+	for _, p := range d.Added.Prefixes {
+		fmt.Fprintf(&sb, "+%s\n", p)
+	}
+	for _, c := range []struct {
+		verb string
+		cov  *ListCoverage
+	}{{"added", d.Added}, {"removed", d.Removed}} {
+		fmt.Fprintf(&sb, "%s %s: %d IPv4 and %s IPv6 addresses\n",
+			c.verb, prefixCount(len(c.cov.Prefixes)), c.cov.IPv4, c.cov.IPv6)
+	}
+	return sb.String()
+}
+
+// prefixCount returns "1 prefix" or "n prefixes".
+func prefixCount(n int) string {
+	if n == 1 {
+		return "1 prefix"
+	}
+	return fmt.Sprintf("%d prefixes", n)
+}
+
+// diffCoverage is the serialized form of one side of a PrefixDiff. The
+// IPv6 count is a string, as it may exceed the precision of JSON
+// numbers in common decoders.
+type diffCoverage struct {
+	Prefixes []netip.Prefix `json:"prefixes"`
+	IPv4     uint64         `json:"ipv4"`
+	IPv6     string         `json:"ipv6"`
+}
+
+// MarshalJSON renders d as a JSON object with added and removed
+// members, each holding the prefixes and the IPv4 and IPv6 address
+// counts.
+func (d *PrefixDiff) MarshalJSON() ([]byte, error) {
+	side := func(c *ListCoverage) diffCoverage {
+		ps := c.Prefixes
+		if ps == nil {
+			ps = []netip.Prefix{}
+		}
+		return diffCoverage{Prefixes: ps, IPv4: c.IPv4, IPv6: c.IPv6.String()}
+	}
+	return json.Marshal(struct {
+		Added   diffCoverage `json:"added"`
+		Removed diffCoverage `json:"removed"`
+	}{side(d.Added), side(d.Removed)})
+}
@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiffPrefixes(t *testing.T) {
+	testpairs := []struct {
+		name    string
+		old     []string
+		new     []string
+		added   string
+		removed string
+		v4      [2]uint64
+		v6      [2]string
+	}{
+		{
+			name: "identical",
+			old:  []string{"10.0.0.0/8", "2001:db8::/32"},
+			new:  []string{"2001:db8::/32", "10.0.0.0/8"},
+			v6:   [2]string{"0", "0"},
+		},
+		{
+			name: "restructured but equivalent",
+			old:  []string{"10.0.0.0/8"},
+			new:  []string{"10.128.0.0/9", "10.0.0.0/9", "10.1.0.0/16"},
+			v6:   [2]string{"0", "0"},
+		},
+		{
+			name:    "grown and shrunk",
+			old:     []string{"192.0.2.0/25", "198.51.100.0/24", "2001:db8::/32"},
+			new:     []string{"192.0.2.0/24", "198.51.100.0/25", "2001:db8::/33"},
+			added:   "192.0.2.128/25",
+			removed: "198.51.100.128/25 2001:db8:8000::/33",
+			v4:      [2]uint64{128, 128},
+			v6:      [2]string{"0", "39614081257132168796771975168"},
+		},
+		{
+			name:  "unaligned entries",
+			old:   []string{"192.0.2.1/24"},
+			new:   []string{"192.0.2.0/24", "192.0.2.7/32", "203.0.113.0/30"},
+			added: "203.0.113.0/30",
+			v4:    [2]uint64{4, 0},
+			v6:    [2]string{"0", "0"},
+		},
+		{
+			name:    "everything removed",
+			old:     []string{"0.0.0.0/0"},
+			removed: "0.0.0.0/0",
+			v4:      [2]uint64{0, 1 << 32},
+			v6:      [2]string{"0", "0"},
+		},
+	}
+
+	for _, pair := range testpairs {
+		d, err := DiffPrefixes(parsePrefixes(t, pair.old...), parsePrefixes(t, pair.new...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := joinPrefixes(d.Added.Prefixes); actual != pair.added {
+			t.Errorf("%s: expected added %q, got %q", pair.name, pair.added, actual)
+		}
+		if actual := joinPrefixes(d.Removed.Prefixes); actual != pair.removed {
+			t.Errorf("%s: expected removed %q, got %q", pair.name, pair.removed, actual)
+		}
+		if actual := [2]uint64{d.Added.IPv4, d.Removed.IPv4}; actual != pair.v4 {
+			t.Errorf("%s: expected IPv4 counts %v, got %v", pair.name, pair.v4, actual)
+		}
+		if actual := [2]string{d.Added.IPv6.String(), d.Removed.IPv6.String()}; actual != pair.v6 {
+			t.Errorf("%s: expected IPv6 counts %v, got %v", pair.name, pair.v6, actual)
+		}
+		if expected := pair.added == "" && pair.removed == ""; d.IsEmpty() != expected {
+			t.Errorf("%s: expected IsEmpty %v, got %v", pair.name, expected, d.IsEmpty())
+		}
+	}
+}
+
+func TestPrefixDiffString(t *testing.T) {
+	d, err := DiffPrefixes(
+		parsePrefixes(t, "192.0.2.0/25", "2001:db8::/32"),
+		parsePrefixes(t, "192.0.2.0/24", "203.0.113.5/32"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `-2001:db8::/32
++192.0.2.128/25
++203.0.113.5/32
+added 2 prefixes: 129 IPv4 and 0 IPv6 addresses
+removed 1 prefix: 0 IPv4 and 79228162514264337593543950336 IPv6 addresses
+`
+	if actual := d.String(); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestPrefixDiffJSON(t *testing.T) {
+	d, err := DiffPrefixes(parsePrefixes(t, "10.0.0.0/8"), parsePrefixes(t, "10.0.0.0/8", "2001:db8::/127"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"added":{"prefixes":["2001:db8::/127"],"ipv4":0,"ipv6":"2"},"removed":{"prefixes":[],"ipv4":0,"ipv6":"0"}}`
+	if actual := string(b); actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+}
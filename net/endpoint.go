@@ -0,0 +1,223 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// servicesFile is the embedded services table, in /etc/services
+// format.
+//
+//go:embed services
+var servicesFile string
+
+// serviceKey identifies a service name or port within a protocol.
+type serviceKey struct {
+	network string
+	name    string
+}
+
+// serviceTable maps service names and aliases to ports, and ports to
+// their primary names, per protocol.
+type serviceTable struct {
+	ports map[serviceKey]uint16
+	names map[serviceKey]string
+}
+
+// services holds the parsed services table.
+var services = sync.OnceValues(func() (*serviceTable, error) {
+	return parseServices(servicesFile)
+})
+
+// parseServices parses a table in /etc/services format.
+func parseServices(data string) (*serviceTable, error) {
+	st := &serviceTable{ports: map[serviceKey]uint16{}, names: map[serviceKey]string{}}
+	sc := bufio.NewScanner(strings.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		text, _, _ := strings.Cut(sc.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("services line %d: missing port", line)
+		}
+		p, network, ok := strings.Cut(fields[1], "/")
+		if !ok {
+			return nil, fmt.Errorf("services line %d: missing protocol", line)
+		}
+		port, err := parsePort(p)
+		if err != nil {
+			return nil, fmt.Errorf("services line %d: %w", line, err)
+		}
+		network = strings.ToLower(network)
+		for _, name := range append(fields[:1], fields[2:]...) {
+			st.ports[serviceKey{network, strings.ToLower(name)}] = port
+		}
+		key := serviceKey{network, strconv.Itoa(int(port))}
+		if _, dup := st.names[key]; !dup {
+			st.names[key] = fields[0]
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// ServicesVersion returns the version of the embedded services table.
+func ServicesVersion() string {
+	sc := bufio.NewScanner(strings.NewReader(servicesFile))
+	for sc.Scan() {
+		if v, ok := strings.CutPrefix(sc.Text(), "# version:"); ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// checkNetwork validates a network name for service lookups and
+// returns the protocols to search, in order.
+func checkNetwork(network string) ([]string, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		return []string{"tcp"}, nil
+	case "udp", "udp4", "udp6":
+		return []string{"udp"}, nil
+	case "":
+		return []string{"tcp", "udp"}, nil
+	default:
+		return nil, fmt.Errorf("unknown network %q", network)
+	}
+}
+
+// LookupPort returns the port for service on network, which is "tcp"
+// or "udp", optionally suffixed with 4 or 6, or "" to try TCP before
+// UDP. Service may also be a decimal port number. Unlike
+// net.LookupPort, only the embedded services table is consulted, never
+// the system's.
+func LookupPort(network, service string) (uint16, error) {
+	protocols, err := checkNetwork(network)
+	if err != nil {
+		return 0, err
+	}
+	if port, err := parsePort(service); err == nil {
+		return port, nil
+	}
+	st, err := services()
+	if err != nil {
+		return 0, err
+	}
+	for _, proto := range protocols {
+		if port, ok := st.ports[serviceKey{proto, strings.ToLower(service)}]; ok {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown service %q", service)
+}
+
+// LookupService returns the name of the service on port over network,
+// as LookupPort would accept it, and whether there is one.
+func LookupService(network string, port uint16) (string, bool) {
+	protocols, err := checkNetwork(network)
+	if err != nil {
+		return "", false
+	}
+	st, err := services()
+	if err != nil {
+		return "", false
+	}
+	for _, proto := range protocols {
+		if name, ok := st.names[serviceKey{proto, strconv.Itoa(int(port))}]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Endpoint is a host and port, where the host is either an IP address
+// or a DNS name. Names are never resolved.
+type Endpoint struct {
+	Host string // the host name, or empty if Addr is set
+	Addr netip.Addr
+	Port uint16
+}
+
+// ParseEndpoint parses s as host:port. IPv6 addresses must be
+// bracketed, and may carry a zone, e.g. [fe80::1%eth0]:443. The port
+// may be a number or a service name, resolved for network as
+// LookupPort does.
+func ParseEndpoint(s, network string) (Endpoint, error) {
+	host, service, err := net.SplitHostPort(s)
+	if err != nil {
+		return Endpoint{}, err
+	}
+	if service == "" {
+		return Endpoint{}, fmt.Errorf("%s: missing port", s)
+	}
+	port, err := LookupPort(network, service)
+	if err != nil {
+		return Endpoint{}, fmt.Errorf("%s: %w", s, err)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if addr.Is6() != strings.HasPrefix(s, "[") {
+			return Endpoint{}, fmt.Errorf("%s: IPv6 addresses, and only they, must be bracketed", s)
+		}
+		return Endpoint{Addr: addr, Port: port}, nil
+	}
+	if strings.HasPrefix(s, "[") {
+		return Endpoint{}, fmt.Errorf("%s: only IPv6 addresses may be bracketed", s)
+	}
+	if err := checkHostname(host); err != nil {
+		return Endpoint{}, fmt.Errorf("%s: %w", s, err)
+	}
+	return Endpoint{Host: host, Port: port}, nil
+}
+
+// checkHostname verifies that host is a syntactically valid DNS name.
+// Underscores are tolerated, as they are common in practice.
+func checkHostname(host string) error {
+	name := strings.TrimSuffix(host, ".")
+	if name == "" || len(name) > 253 {
+		return fmt.Errorf("invalid host name %q", host)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return fmt.Errorf("invalid host name %q", host)
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return fmt.Errorf("invalid host name %q", host)
+			}
+		}
+	}
+	return nil
+}
+
+// AddrPort returns e as a netip.AddrPort. It fails if e names a host
+// rather than an address.
+func (e Endpoint) AddrPort() (netip.AddrPort, error) {
+	if !e.Addr.IsValid() {
+		return netip.AddrPort{}, errors.New("endpoint has no IP address")
+	}
+	return netip.AddrPortFrom(e.Addr, e.Port), nil
+}
+
+// String returns e in host:port form, bracketing IPv6 addresses.
+func (e Endpoint) String() string {
+	host := e.Host
+	if e.Addr.IsValid() {
+		host = e.Addr.String()
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(e.Port)))
+}
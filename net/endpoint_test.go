@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestServicesVersion(t *testing.T) {
+	if v := ServicesVersion(); v == "" {
+		t.Error("expected a version")
+	}
+}
+
+func TestLookupPort(t *testing.T) {
+	testpairs := []struct {
+		network  string
+		service  string
+		expected uint16
+	}{
+		{network: "tcp", service: "ssh", expected: 22},
+		{network: "tcp", service: "HTTP", expected: 80},
+		{network: "tcp", service: "www", expected: 80},
+		{network: "tcp6", service: "https", expected: 443},
+		{network: "udp", service: "domain", expected: 53},
+		{network: "", service: "ntp", expected: 123},
+		{network: "udp", service: "8080", expected: 8080},
+		{network: "", service: "postgres", expected: 5432},
+	}
+
+	for _, pair := range testpairs {
+		actual, err := LookupPort(pair.network, pair.service)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != pair.expected {
+			t.Errorf("%s/%s: expected %d, got %d", pair.service, pair.network, pair.expected, actual)
+		}
+	}
+
+	for _, pair := range []struct{ network, service string }{
+		{"udp", "ssh"},
+		{"tcp", "nosuchservice"},
+		{"tcp", "65536"},
+		{"sctp", "80"},
+	} {
+		if _, err := LookupPort(pair.network, pair.service); err == nil {
+			t.Errorf("%s/%s: expected error", pair.service, pair.network)
+		}
+	}
+}
+
+func TestLookupService(t *testing.T) {
+	if name, ok := LookupService("tcp", 443); !ok || name != "https" {
+		t.Errorf("expected https, got %q", name)
+	}
+	if name, ok := LookupService("", 161); !ok || name != "snmp" {
+		t.Errorf("expected snmp, got %q", name)
+	}
+	if name, ok := LookupService("tcp", 161); ok {
+		t.Errorf("expected no service, got %q", name)
+	}
+}
+
+func TestParseEndpoint(t *testing.T) {
+	testpairs := []struct {
+		input    string
+		network  string
+		expected Endpoint
+		str      string
+	}{
+		{input: "192.0.2.1:80", expected: Endpoint{Addr: netip.MustParseAddr("192.0.2.1"), Port: 80}, str: "192.0.2.1:80"},
+		{input: "[2001:db8::1]:443", expected: Endpoint{Addr: netip.MustParseAddr("2001:db8::1"), Port: 443}, str: "[2001:db8::1]:443"},
+		{input: "[fe80::1%eth0]:https", network: "tcp", expected: Endpoint{Addr: netip.MustParseAddr("fe80::1%eth0"), Port: 443}, str: "[fe80::1%eth0]:443"},
+		{input: "example.com:ssh", network: "tcp", expected: Endpoint{Host: "example.com", Port: 22}, str: "example.com:22"},
+		{input: "_sip._udp.example.com.:5060", expected: Endpoint{Host: "_sip._udp.example.com.", Port: 5060}, str: "_sip._udp.example.com.:5060"},
+		{input: "localhost:domain", network: "udp", expected: Endpoint{Host: "localhost", Port: 53}, str: "localhost:53"},
+	}
+
+	for _, pair := range testpairs {
+		actual, err := ParseEndpoint(pair.input, pair.network)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != pair.expected {
+			t.Errorf("%s: expected %+v, got %+v", pair.input, pair.expected, actual)
+		}
+		if s := actual.String(); s != pair.str {
+			t.Errorf("%s: expected %s, got %s", pair.input, pair.str, s)
+		}
+	}
+
+	for _, s := range []string{
+		"192.0.2.1",
+		"192.0.2.1:",
+		"2001:db8::1:80",
+		"[192.0.2.1]:80",
+		"[example.com]:80",
+		"example.com:nosuchservice",
+		"example.com:65536",
+		"-bad.example.com:80",
+		"bad..example.com:80",
+		"exa mple.com:80",
+		":80",
+	} {
+		if _, err := ParseEndpoint(s, "tcp"); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+func TestEndpointAddrPort(t *testing.T) {
+	e, err := ParseEndpoint("[2001:db8::1]:53", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ap, err := e.AddrPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := netip.MustParseAddrPort("[2001:db8::1]:53"); ap != expected {
+		t.Errorf("expected %v, got %v", expected, ap)
+	}
+	if _, err := (Endpoint{Host: "example.com", Port: 53}).AddrPort(); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,231 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Notation identifies how an IP address was written.
+type Notation int
+
+const (
+	// NotationIPv4 is dotted-decimal IPv4, e.g. 192.0.2.1.
+	NotationIPv4 Notation = iota
+	// NotationCanonical is the RFC 5952 canonical IPv6 form, e.g.
+	// 2001:db8::1 or, for IPv4-mapped addresses, ::ffff:192.0.2.1.
+	NotationCanonical
+	// NotationExpanded is the fully expanded IPv6 form, e.g.
+	// 2001:0db8:0000:0000:0000:0000:0000:0001.
+	NotationExpanded
+	// NotationNonCanonical is any other valid colon-hexadecimal IPv6
+	// form, e.g. 2001:DB8:0:0::1.
+	NotationNonCanonical
+	// NotationDottedQuad is IPv6 with an embedded dotted-decimal IPv4
+	// suffix, e.g. 64:ff9b::192.0.2.1.
+	NotationDottedQuad
+	// NotationURL is a bracketed IPv6 URL host with an RFC 6874 escaped
+	// zone, e.g. [fe80::1%25eth0].
+	NotationURL
+)
+
+// String returns the name of n.
+func (n Notation) String() string {
+	switch n {
+	case NotationIPv4:
+		return "IPv4"
+	case NotationCanonical:
+		return "canonical"
+	case NotationExpanded:
+		return "expanded"
+	case NotationNonCanonical:
+		return "non-canonical"
+	case NotationDottedQuad:
+		return "dotted-quad"
+	case NotationURL:
+		return "URL"
+	default:
+		return "Notation(" + strconv.Itoa(int(n)) + ")"
+	}
+}
+
+// groups returns the eight 16-bit groups of an IPv6 address.
+func groups(addr netip.Addr) [8]uint16 {
+	b := addr.As16()
+	var g [8]uint16
+	for i := range g {
+		g[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+	}
+	return g
+}
+
+// compress writes gs in RFC 5952 form: lowercase hexadecimal without
+// leading zeros, with the longest run of two or more zero groups (the
+// first, if tied) replaced by "::".
+func compress(sb *strings.Builder, gs []uint16) {
+	start, length := -1, 1
+	for i := 0; i < len(gs); {
+		if gs[i] != 0 {
+			i++
+			continue
+		}
+		j := i
+		for j < len(gs) && gs[j] == 0 {
+			j++
+		}
+		if j-i > length {
+			start, length = i, j-i
+		}
+		i = j
+	}
+	for i := 0; i < len(gs); i++ {
+		if i == start {
+			sb.WriteString("::")
+			i += length - 1
+			continue
+		}
+		if i > 0 && i != start+length {
+			sb.WriteByte(':')
+		}
+		sb.WriteString(strconv.FormatUint(uint64(gs[i]), 16))
+	}
+}
+
+// withZone appends the zone of addr, if any, to s.
+func withZone(s string, addr netip.Addr) string {
+	if z := addr.Zone(); z != "" {
+		return s + "%" + z
+	}
+	return s
+}
+
+// FormatCanonical returns addr in RFC 5952 canonical form. IPv4
+// addresses are returned in dotted-decimal form, and IPv4-mapped
+// addresses in the mixed notation RFC 5952 section 5 recommends, e.g.
+// ::ffff:192.0.2.1.
+func FormatCanonical(addr netip.Addr) string {
+	if !addr.Is6() {
+		return addr.String()
+	}
+	if addr.Is4In6() {
+		return FormatDottedQuad(addr)
+	}
+	g := groups(addr)
+	var sb strings.Builder
+	compress(&sb, g[:])
+	return withZone(sb.String(), addr)
+}
+
+// FormatExpanded returns addr in fully expanded form, with all eight
+// groups written as four lowercase hexadecimal digits. IPv4 addresses
+// are returned in dotted-decimal form.
+func FormatExpanded(addr netip.Addr) string {
+	if !addr.Is6() {
+		return addr.String()
+	}
+	var sb strings.Builder
+	for i, g := range groups(addr) {
+		if i > 0 {
+			sb.WriteByte(':')
+		}
+		fmt.Fprintf(&sb, "%04x", g)
+	}
+	return withZone(sb.String(), addr)
+}
+
+// FormatDottedQuad returns addr with its final 32 bits written in
+// dotted-decimal form and the rest in RFC 5952 form, e.g.
+// 64:ff9b::192.0.2.1. IPv4 addresses are returned in dotted-decimal
+// form.
+func FormatDottedQuad(addr netip.Addr) string {
+	if !addr.Is6() {
+		return addr.String()
+	}
+	g := groups(addr)
+	b := addr.As16()
+	var sb strings.Builder
+	compress(&sb, g[:6])
+	if s := sb.String(); !strings.HasSuffix(s, "::") {
+		sb.WriteByte(':')
+	}
+	fmt.Fprintf(&sb, "%d.%d.%d.%d", b[12], b[13], b[14], b[15])
+	return withZone(sb.String(), addr)
+}
+
+// FormatURLHost returns addr in a form suitable for the host part of
+// a URL. IPv6 addresses are bracketed, and their zone, if any, is
+// introduced by "%25" and percent-encoded as RFC 6874 requires.
+func FormatURLHost(addr netip.Addr) string {
+	if !addr.Is6() {
+		return addr.String()
+	}
+	s := "[" + FormatCanonical(addr.WithZone(""))
+	if z := addr.Zone(); z != "" {
+		s += "%25" + url.PathEscape(z)
+	}
+	return s + "]"
+}
+
+// ParseNotation parses s as an IPv4 or IPv6 address and reports the
+// notation it was written in. The canonical form of an IPv4-mapped
+// address, such as ::ffff:192.0.2.1, is NotationCanonical. Anything
+// netip.ParseAddr rejects, such as IPv4 octets with leading zeros, is
+// rejected, as are bracketed addresses whose zone is not introduced by
+// "%25".
+func ParseNotation(s string) (netip.Addr, Notation, error) {
+	if strings.HasPrefix(s, "[") {
+		return parseURLHost(s)
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, 0, err
+	}
+	host, _, _ := strings.Cut(s, "%")
+	switch {
+	case addr.Is4():
+		return addr, NotationIPv4, nil
+	case s == FormatCanonical(addr):
+		return addr, NotationCanonical, nil
+	case strings.Contains(host, "."):
+		return addr, NotationDottedQuad, nil
+	case s == FormatExpanded(addr):
+		return addr, NotationExpanded, nil
+	default:
+		return addr, NotationNonCanonical, nil
+	}
+}
+
+// parseURLHost parses a bracketed IPv6 URL host.
+func parseURLHost(s string) (netip.Addr, Notation, error) {
+	inner, ok := strings.CutSuffix(strings.TrimPrefix(s, "["), "]")
+	if !ok {
+		return netip.Addr{}, 0, fmt.Errorf("%s: missing closing bracket", s)
+	}
+	host, zone, zoned := strings.Cut(inner, "%")
+	if zoned {
+		escaped, ok := strings.CutPrefix(zone, "25")
+		if !ok || escaped == "" {
+			return netip.Addr{}, 0, fmt.Errorf("%s: zone must be introduced by %%25", s)
+		}
+		var err error
+		if zone, err = url.PathUnescape(escaped); err != nil {
+			return netip.Addr{}, 0, fmt.Errorf("%s: %w", s, err)
+		}
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, 0, err
+	}
+	if !addr.Is6() {
+		return netip.Addr{}, 0, fmt.Errorf("%s: only IPv6 addresses may be bracketed", s)
+	}
+	if zoned {
+		addr = addr.WithZone(zone)
+	}
+	return addr, NotationURL, nil
+}
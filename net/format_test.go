@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestFormatCanonical(t *testing.T) {
+	testpairs := []struct {
+		input    string
+		expected string
+	}{
+		{input: ipv6addr, expected: "2402:9400::1"},
+		{input: "2001:0db8:0000:0000:0001:0000:0000:0001", expected: "2001:db8::1:0:0:1"},
+		{input: "2001:db8:0:1:1:1:1:1", expected: "2001:db8:0:1:1:1:1:1"},
+		{input: "2001:DB8::ABCD", expected: "2001:db8::abcd"},
+		{input: "2001:0:0:1:0:0:0:1", expected: "2001:0:0:1::1"},
+		{input: "0:0:0:0:0:0:0:0", expected: "::"},
+		{input: "0:0:0:0:0:0:0:1", expected: "::1"},
+		{input: "1:0:0:0:0:0:0:0", expected: "1::"},
+		{input: "fe80::1%eth0", expected: "fe80::1%eth0"},
+		{input: "::ffff:192.0.2.1", expected: "::ffff:192.0.2.1"},
+		{input: "::ffff:c000:201%eth0", expected: "::ffff:192.0.2.1%eth0"},
+		{input: "::c000:201", expected: "::c000:201"},
+		{input: "192.0.2.1", expected: "192.0.2.1"},
+	}
+
+	for _, pair := range testpairs {
+		actual := FormatCanonical(netip.MustParseAddr(pair.input))
+		if actual != pair.expected {
+			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
+		}
+	}
+}
+
+func TestFormatExpanded(t *testing.T) {
+	testpairs := []struct {
+		input    string
+		expected string
+	}{
+		{input: "2402:9400::1", expected: ipv6addr},
+		{input: "::", expected: "0000:0000:0000:0000:0000:0000:0000:0000"},
+		{input: "fe80::1%eth0", expected: "fe80:0000:0000:0000:0000:0000:0000:0001%eth0"},
+		{input: "192.0.2.1", expected: "192.0.2.1"},
+	}
+
+	for _, pair := range testpairs {
+		actual := FormatExpanded(netip.MustParseAddr(pair.input))
+		if actual != pair.expected {
+			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
+		}
+	}
+}
+
+func TestFormatDottedQuad(t *testing.T) {
+	testpairs := []struct {
+		input    string
+		expected string
+	}{
+		{input: "64:ff9b::c000:201", expected: "64:ff9b::192.0.2.1"},
+		{input: "::ffff:c000:201", expected: "::ffff:192.0.2.1"},
+		{input: "::c000:201", expected: "::192.0.2.1"},
+		{input: "1:2:3:4:5:0:c000:201", expected: "1:2:3:4:5:0:192.0.2.1"},
+		{input: "1:2:3:4:5:6:c000:201", expected: "1:2:3:4:5:6:192.0.2.1"},
+		{input: "1:2:3:4::c000:201", expected: "1:2:3:4::192.0.2.1"},
+		{input: "192.0.2.1", expected: "192.0.2.1"},
+	}
+
+	for _, pair := range testpairs {
+		actual := FormatDottedQuad(netip.MustParseAddr(pair.input))
+		if actual != pair.expected {
+			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
+		}
+	}
+}
+
+func TestFormatURLHost(t *testing.T) {
+	testpairs := []struct {
+		input    string
+		expected string
+	}{
+		{input: "2001:db8::1", expected: "[2001:db8::1]"},
+		{input: "fe80::1%eth0", expected: "[fe80::1%25eth0]"},
+		{input: "fe80::1%en 1", expected: "[fe80::1%25en%201]"},
+		{input: "192.0.2.1", expected: "192.0.2.1"},
+	}
+
+	for _, pair := range testpairs {
+		addr := netip.MustParseAddr(pair.input)
+		actual := FormatURLHost(addr)
+		if actual != pair.expected {
+			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
+		}
+		parsed, _, err := ParseNotation(actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed != addr {
+			t.Errorf("%s: expected %v, got %v", actual, addr, parsed)
+		}
+	}
+}
+
+func TestParseNotation(t *testing.T) {
+	testpairs := []struct {
+		input    string
+		expected Notation
+	}{
+		{input: "192.0.2.1", expected: NotationIPv4},
+		{input: "2001:db8::1", expected: NotationCanonical},
+		{input: "fe80::1%eth0", expected: NotationCanonical},
+		{input: ipv6addr, expected: NotationExpanded},
+		{input: "2001:DB8::1", expected: NotationNonCanonical},
+		{input: "2001:db8:0:0::1", expected: NotationNonCanonical},
+		{input: "2001:0db8::1", expected: NotationNonCanonical},
+		{input: "::ffff:192.0.2.1", expected: NotationCanonical},
+		{input: "::ffff:c000:201", expected: NotationNonCanonical},
+		{input: "::FFFF:192.0.2.1", expected: NotationDottedQuad},
+		{input: "64:ff9b::192.0.2.1", expected: NotationDottedQuad},
+		{input: "[2001:db8::1]", expected: NotationURL},
+		{input: "[fe80::1%25eth0]", expected: NotationURL},
+	}
+
+	for _, pair := range testpairs {
+		_, actual, err := ParseNotation(pair.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != pair.expected {
+			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
+		}
+	}
+}
+
+func TestParseNotationErrors(t *testing.T) {
+	inputs := []string{
+		"",
+		"192.0.2.01",
+		"2001:db8::1::1",
+		"[2001:db8::1",
+		"[192.0.2.1]",
+		"[fe80::1%eth0]",
+		"[fe80::1%25]",
+		"[fe80::1%25%zz]",
+	}
+
+	for _, input := range inputs {
+		addr, n, err := ParseNotation(input)
+		if err == nil {
+			t.Errorf("%q: expected error, got %v (%v)", input, addr, n)
+		}
+	}
+}
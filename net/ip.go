@@ -6,11 +6,8 @@ package net
 import (
 	"errors"
 	"fmt"
-	"math"
 	"math/big"
 	"net"
-
-	ymath "github.com/yesmar/y/math"
 )
 
 // Nipsv4 computes the number of IP addresses in the given IPv4 CIDR.
@@ -21,10 +18,11 @@ func Nipsv4(cidr *net.IPNet) (uint64, error) {
 	if cidr.IP.To4() == nil {
 		return 0, fmt.Errorf("%s is not an IPv4 address", cidr.IP)
 	}
-	netbits, bits := cidr.Mask.Size()
-	hostbits := bits - netbits
-	c := math.Pow(2, float64(hostbits))
-	return uint64(c), nil
+	hb, err := hostbits(cidr)
+	if err != nil {
+		return 0, err
+	}
+	return 1 << hb, nil
 }
 
 // Nipsv6 computes the number of IP addresses in the given IPv6 CIDR.
@@ -35,12 +33,45 @@ func Nipsv6(cidr *net.IPNet) (*big.Int, error) {
 	if cidr.IP.To4() != nil {
 		return nil, fmt.Errorf("%s is not an IPv6 address", cidr.IP)
 	}
-	netbits, bits := cidr.Mask.Size()
-	hostbits := bits - netbits
-	c := math.Pow(2, float64(hostbits))
-	n, err := ymath.BigFloatToBigInt(big.NewFloat(c))
+	hb, err := hostbits(cidr)
 	if err != nil {
 		return nil, err
 	}
-	return n, nil
+	return new(big.Int).Lsh(big.NewInt(1), hb), nil
+}
+
+// Nips computes the number of IP addresses in the given CIDR, which
+// may be either IPv4 or IPv6. The result is always a big.Int so that
+// callers need not branch on the address family.
+func Nips(cidr *net.IPNet) (*big.Int, error) {
+	if cidr == nil {
+		return nil, errors.New("nil net.IPNet")
+	}
+	if cidr.IP.To4() != nil {
+		n, err := Nipsv4(cidr)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetUint64(n), nil
+	}
+	return Nipsv6(cidr)
+}
+
+// hostbits returns the number of host bits in cidr. IPv4 networks
+// expressed with a 16-byte mask are measured against 32 bits rather
+// than 128, so that the result always fits the address family.
+// Non-contiguous masks are rejected.
+func hostbits(cidr *net.IPNet) (uint, error) {
+	netbits, bits, err := checkMask(cidr.Mask)
+	if err != nil {
+		return 0, err
+	}
+	if bits == 8*net.IPv6len && cidr.IP.To4() != nil {
+		if netbits < 8*(net.IPv6len-net.IPv4len) {
+			return 0, fmt.Errorf("%s is not a valid IPv4 mask", cidr.Mask)
+		}
+		netbits -= 8 * (net.IPv6len - net.IPv4len)
+		bits -= 8 * (net.IPv6len - net.IPv4len)
+	}
+	return uint(bits - netbits), nil
 }
@@ -29,7 +29,7 @@ func TestNipsv4(t *testing.T) {
 		{input: fmt.Sprintf("%s/5", ipv4addr), expected: 134217728},
 		{input: fmt.Sprintf("%s/6", ipv4addr), expected: 67108864},
 		{input: fmt.Sprintf("%s/7", ipv4addr), expected: 33554432},
-		{input: fmt.Sprintf("%s/8", ipv4addr), expected: 16777214},
+		{input: fmt.Sprintf("%s/8", ipv4addr), expected: 16777216},
 		{input: fmt.Sprintf("%s/9", ipv4addr), expected: 8388608},
 		{input: fmt.Sprintf("%s/10", ipv4addr), expected: 4194304},
 		{input: fmt.Sprintf("%s/11", ipv4addr), expected: 2097152},
@@ -66,6 +66,9 @@ func TestNipsv4(t *testing.T) {
 			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
 			log.Fatal(err)
 		}
+		if actual != pair.expected {
+			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
+		}
 	}
 }
 
@@ -455,7 +458,7 @@ func TestNipsv6(t *testing.T) {
 		{input: fmt.Sprintf("%s/71", ipv6addr), expected: big.NewInt(144115188075855872)},
 		{input: fmt.Sprintf("%s/72", ipv6addr), expected: big.NewInt(72057594037927936)},
 		{input: fmt.Sprintf("%s/73", ipv6addr), expected: big.NewInt(36028797018963968)},
-		{input: fmt.Sprintf("%s/74", ipv6addr), expected: big.NewInt(18014398509481985)},
+		{input: fmt.Sprintf("%s/74", ipv6addr), expected: big.NewInt(18014398509481984)},
 		{input: fmt.Sprintf("%s/75", ipv6addr), expected: big.NewInt(9007199254740992)},
 		{input: fmt.Sprintf("%s/76", ipv6addr), expected: big.NewInt(4503599627370496)},
 		{input: fmt.Sprintf("%s/77", ipv6addr), expected: big.NewInt(2251799813685248)},
@@ -522,6 +525,9 @@ func TestNipsv6(t *testing.T) {
 			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
 			log.Fatal(err)
 		}
+		if actual.Cmp(pair.expected) != 0 {
+			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
+		}
 	}
 }
 
@@ -543,3 +549,94 @@ func TestNipsv6WithIPv4(t *testing.T) {
 		t.Error("Expected nil, got", actual)
 	}
 }
+
+func TestNipsv6Exact(t *testing.T) {
+	testpairs := []struct {
+		input    string
+		expected string
+	}{
+		{input: "::/0", expected: "340282366920938463463374607431768211456"},
+		{input: "::/1", expected: "170141183460469231731687303715884105728"},
+		{input: "8000::/7", expected: "2658455991569831745807614120560689152"},
+		{input: fmt.Sprintf("%s/63", ipv6addr), expected: "36893488147419103232"},
+	}
+
+	for _, pair := range testpairs {
+		_, ipn, err := net.ParseCIDR(pair.input)
+		if err != nil {
+			t.Fatal("failed to parse", pair.input)
+		}
+		expected, ok := new(big.Int).SetString(pair.expected, 10)
+		if !ok {
+			t.Fatal("failed to parse", pair.expected)
+		}
+		actual, err := Nipsv6(ipn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.Cmp(expected) != 0 {
+			t.Errorf("%s: expected %v, got %v", pair.input, expected, actual)
+		}
+	}
+}
+
+func TestNipsv4MappedMask(t *testing.T) {
+	ipn := &net.IPNet{IP: net.ParseIP("10.0.0.0"), Mask: net.CIDRMask(104, 128)}
+	actual, err := Nipsv4(ipn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != 16777216 {
+		t.Errorf("%s: expected %v, got %v", ipn, 16777216, actual)
+	}
+}
+
+func TestNipsNonContiguousMask(t *testing.T) {
+	ipn := &net.IPNet{IP: net.ParseIP("10.0.0.0"), Mask: net.IPv4Mask(255, 0, 255, 0)}
+	if actual, err := Nipsv4(ipn); err == nil {
+		t.Errorf("%s: expected error, got %v", ipn, actual)
+	}
+	ipn = &net.IPNet{IP: net.ParseIP("2001:db8::"), Mask: net.IPMask(net.ParseIP("ffff::ffff"))}
+	if actual, err := Nipsv6(ipn); err == nil {
+		t.Errorf("%s: expected error, got %v", ipn, actual)
+	}
+	ipn = &net.IPNet{IP: net.ParseIP("10.0.0.0"), Mask: net.IPMask{255, 255, 0}}
+	if actual, err := Nips(ipn); err == nil {
+		t.Errorf("%s: expected error, got %v", ipn, actual)
+	}
+}
+
+func TestNips(t *testing.T) {
+	testpairs := []struct {
+		input    string
+		expected string
+	}{
+		{input: fmt.Sprintf("%s/0", ipv4addr), expected: "4294967296"},
+		{input: fmt.Sprintf("%s/24", ipv4addr), expected: "256"},
+		{input: fmt.Sprintf("%s/32", ipv4addr), expected: "1"},
+		{input: fmt.Sprintf("%s/0", ipv6addr), expected: "340282366920938463463374607431768211456"},
+		{input: fmt.Sprintf("%s/64", ipv6addr), expected: "18446744073709551616"},
+		{input: fmt.Sprintf("%s/128", ipv6addr), expected: "1"},
+	}
+
+	for _, pair := range testpairs {
+		_, ipn, err := net.ParseCIDR(pair.input)
+		if err != nil {
+			t.Fatal("failed to parse", pair.input)
+		}
+		actual, err := Nips(ipn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.String() != pair.expected {
+			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
+		}
+	}
+}
+
+func TestNilNips(t *testing.T) {
+	actual, err := Nips(nil)
+	if err == nil {
+		t.Error("Expected nil, got", actual)
+	}
+}
@@ -0,0 +1,317 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/netip"
+	"slices"
+	"sync"
+)
+
+// ErrPoolExhausted is returned when no pool can satisfy an allocation.
+var ErrPoolExhausted = errors.New("address pools exhausted")
+
+// Allocator hands out addresses and sub-prefixes from one or more
+// pools. Individual addresses are taken from the usable host range of
+// a pool, as reported by NewSubnetInfo, while sub-prefixes may use the
+// whole pool. Reserved prefixes are never handed out. The zero value is
+// an Allocator without any pools. An Allocator is safe for concurrent
+// use.
+type Allocator struct {
+	mu        sync.Mutex
+	pools     []netip.Prefix
+	allocated map[netip.Prefix]bool
+	reserved  map[netip.Prefix]bool
+	used      IPSet // allocated and reserved addresses
+}
+
+// allocatorVersion is the version of the serialized Allocator state.
+const allocatorVersion = 1
+
+// allocatorState is the serialized form of an Allocator.
+type allocatorState struct {
+	Version   int            `json:"version"`
+	Pools     []netip.Prefix `json:"pools"`
+	Allocated []netip.Prefix `json:"allocated"`
+	Reserved  []netip.Prefix `json:"reserved"`
+}
+
+// NewAllocator returns an Allocator drawing from pools, which must not
+// overlap.
+func NewAllocator(pools ...netip.Prefix) (*Allocator, error) {
+	a := &Allocator{}
+	for _, p := range pools {
+		if err := a.addPool(p); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// AddPool adds p to the pools a draws from. It must not overlap any
+// existing pool.
+func (a *Allocator) AddPool(p netip.Prefix) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.addPool(p)
+}
+
+func (a *Allocator) addPool(p netip.Prefix) error {
+	if !p.IsValid() {
+		return errors.New("invalid netip.Prefix")
+	}
+	if a.allocated == nil {
+		a.allocated, a.reserved = map[netip.Prefix]bool{}, map[netip.Prefix]bool{}
+	}
+	p = p.Masked()
+	for _, q := range a.pools {
+		if q.Overlaps(p) {
+			return fmt.Errorf("pool %s overlaps pool %s", p, q)
+		}
+	}
+	a.pools = append(a.pools, p)
+	return nil
+}
+
+// Pools returns the pools a draws from.
+func (a *Allocator) Pools() []netip.Prefix {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return slices.Clone(a.pools)
+}
+
+// Allocated returns the prefixes currently allocated, in ascending
+// order. Individual addresses appear as /32 or /128 prefixes.
+func (a *Allocator) Allocated() []netip.Prefix {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return sortedKeys(a.allocated)
+}
+
+// Reserved returns the prefixes currently reserved, in ascending order.
+func (a *Allocator) Reserved() []netip.Prefix {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return sortedKeys(a.reserved)
+}
+
+// Available returns the number of addresses in a's pools that are
+// neither allocated nor reserved.
+func (a *Allocator) Available() *big.Int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	pools, _ := NewIPSet(a.pools...)
+	return pools.Difference(a.used).Size()
+}
+
+// AllocateAddr allocates the lowest free usable host address from the
+// first pool that has one.
+func (a *Allocator) AllocateAddr() (netip.Addr, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, p := range a.pools {
+		si, err := NewSubnetInfo(p)
+		if err != nil {
+			return netip.Addr{}, err
+		}
+		usable, err := NewIPSetFromRanges(IPRange{From: si.First, To: si.Last})
+		if err != nil {
+			return netip.Addr{}, err
+		}
+		free := usable.Difference(a.used)
+		if free.IsEmpty() {
+			continue
+		}
+		addr := free.ranges[0].From
+		a.take(netip.PrefixFrom(addr, addr.BitLen()), a.allocated)
+		return addr, nil
+	}
+	return netip.Addr{}, ErrPoolExhausted
+}
+
+// AllocatePrefix allocates the lowest free prefix of length bits from
+// the first pool that has one.
+func (a *Allocator) AllocatePrefix(bits int) (netip.Prefix, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, p := range a.pools {
+		if bits < p.Bits() || bits > p.Addr().BitLen() {
+			continue
+		}
+		pool, _ := NewIPSet(p)
+		if q, ok := firstAligned(pool.Difference(a.used), bits); ok {
+			a.take(q, a.allocated)
+			return q, nil
+		}
+	}
+	return netip.Prefix{}, ErrPoolExhausted
+}
+
+// firstAligned returns the lowest prefix of length bits lying wholly
+// within s.
+func firstAligned(s IPSet, bits int) (netip.Prefix, bool) {
+	for _, r := range s.ranges {
+		width := r.From.BitLen()
+		if bits > width {
+			continue
+		}
+		mask := hostMask(width - bits)
+		start, overflow := u128(r.From).add(mask)
+		if overflow {
+			continue
+		}
+		start = start.and(mask.not())
+		if end := start.or(mask); end.cmp(u128(r.To)) <= 0 {
+			return netip.PrefixFrom(start.addr(r.From.Is4()), bits), true
+		}
+	}
+	return netip.Prefix{}, false
+}
+
+// AllocateSpecific allocates exactly p, which must lie within a pool
+// and must not overlap anything already allocated or reserved.
+func (a *Allocator) AllocateSpecific(p netip.Prefix) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.check(p); err != nil {
+		return err
+	}
+	a.take(p.Masked(), a.allocated)
+	return nil
+}
+
+// AllocateSpecificAddr allocates exactly addr.
+func (a *Allocator) AllocateSpecificAddr(addr netip.Addr) error {
+	return a.AllocateSpecific(netip.PrefixFrom(addr.WithZone(""), addr.BitLen()))
+}
+
+// Reserve sets p aside so that it is never allocated. It must lie
+// within a pool and must not overlap anything already allocated or
+// reserved.
+func (a *Allocator) Reserve(p netip.Prefix) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.check(p); err != nil {
+		return err
+	}
+	a.take(p.Masked(), a.reserved)
+	return nil
+}
+
+// Release returns the previously allocated prefix p to its pool.
+func (a *Allocator) Release(p netip.Prefix) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.give(p.Masked(), a.allocated, "allocated")
+}
+
+// ReleaseAddr returns the previously allocated address addr to its
+// pool.
+func (a *Allocator) ReleaseAddr(addr netip.Addr) error {
+	return a.Release(netip.PrefixFrom(addr.WithZone(""), addr.BitLen()))
+}
+
+// Unreserve makes the previously reserved prefix p available again.
+func (a *Allocator) Unreserve(p netip.Prefix) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.give(p.Masked(), a.reserved, "reserved")
+}
+
+// check verifies that p lies within a pool and is entirely free.
+func (a *Allocator) check(p netip.Prefix) error {
+	if !p.IsValid() {
+		return errors.New("invalid netip.Prefix")
+	}
+	if !slices.ContainsFunc(a.pools, func(q netip.Prefix) bool {
+		return q.Bits() <= p.Bits() && q.Contains(p.Addr())
+	}) {
+		return fmt.Errorf("%s is not within any pool", p)
+	}
+	if a.used.OverlapsPrefix(p) {
+		return fmt.Errorf("%s overlaps an existing allocation or reservation", p)
+	}
+	return nil
+}
+
+// take records p in m and marks its addresses as used.
+func (a *Allocator) take(p netip.Prefix, m map[netip.Prefix]bool) {
+	m[p] = true
+	a.used = a.used.Union(IPSet{[]IPRange{IPRangeFromPrefix(p)}})
+}
+
+// give removes p from m and marks its addresses as free.
+func (a *Allocator) give(p netip.Prefix, m map[netip.Prefix]bool, what string) error {
+	if !m[p] {
+		return fmt.Errorf("%s is not %s", p, what)
+	}
+	delete(m, p)
+	a.used = a.used.Difference(IPSet{[]IPRange{IPRangeFromPrefix(p)}})
+	return nil
+}
+
+// MarshalJSON serializes the pools, allocations and reservations of a.
+func (a *Allocator) MarshalJSON() ([]byte, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return json.Marshal(allocatorState{
+		Version:   allocatorVersion,
+		Pools:     a.pools,
+		Allocated: sortedKeys(a.allocated),
+		Reserved:  sortedKeys(a.reserved),
+	})
+}
+
+// UnmarshalJSON restores state produced by MarshalJSON, replacing the
+// current state of a. The state is validated as if every pool,
+// reservation and allocation were made afresh.
+func (a *Allocator) UnmarshalJSON(data []byte) error {
+	var st allocatorState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return err
+	}
+	if st.Version != allocatorVersion {
+		return fmt.Errorf("unsupported allocator state version %d", st.Version)
+	}
+	b, err := NewAllocator(st.Pools...)
+	if err != nil {
+		return err
+	}
+	for _, p := range st.Reserved {
+		if err := b.check(p); err != nil {
+			return err
+		}
+		b.take(p.Masked(), b.reserved)
+	}
+	for _, p := range st.Allocated {
+		if err := b.check(p); err != nil {
+			return err
+		}
+		b.take(p.Masked(), b.allocated)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pools, a.allocated, a.reserved, a.used = b.pools, b.allocated, b.reserved, b.used
+	return nil
+}
+
+// sortedKeys returns the keys of m in ascending order.
+func sortedKeys(m map[netip.Prefix]bool) []netip.Prefix {
+	out := make([]netip.Prefix, 0, len(m))
+	for p := range m {
+		out = append(out, p)
+	}
+	slices.SortFunc(out, func(p, q netip.Prefix) int {
+		if c := p.Addr().Compare(q.Addr()); c != 0 {
+			return c
+		}
+		return p.Bits() - q.Bits()
+	})
+	return out
+}
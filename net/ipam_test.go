@@ -0,0 +1,223 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"sync"
+	"testing"
+)
+
+func TestAllocatorAddrs(t *testing.T) {
+	a, err := NewAllocator(netip.MustParsePrefix("192.168.0.0/30"), netip.MustParsePrefix("2001:db8::/127"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Reserve(netip.MustParsePrefix("192.168.0.1/32")); err != nil {
+		t.Fatal(err)
+	}
+
+	var actual []string
+	for {
+		addr, err := a.AllocateAddr()
+		if errors.Is(err, ErrPoolExhausted) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual = append(actual, addr.String())
+	}
+	// The network and broadcast addresses of the IPv4 pool are never
+	// handed out, nor is the reserved address.
+	expected := []string{"192.168.0.2", "2001:db8::", "2001:db8::1"}
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, actual)
+		}
+	}
+
+	if err := a.ReleaseAddr(netip.MustParseAddr("2001:db8::")); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ReleaseAddr(netip.MustParseAddr("2001:db8::")); err == nil {
+		t.Error("expected error releasing an address twice")
+	}
+	if addr, err := a.AllocateAddr(); err != nil || addr.String() != "2001:db8::" {
+		t.Errorf("expected 2001:db8::, got %v %v", addr, err)
+	}
+}
+
+func TestAllocatorPrefixes(t *testing.T) {
+	a, err := NewAllocator(netip.MustParsePrefix("10.0.0.0/22"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AllocateSpecificAddr(netip.MustParseAddr("10.0.0.5")); err != nil {
+		t.Fatal(err)
+	}
+
+	var actual []netip.Prefix
+	for _, bits := range []int{24, 25, 23, 26} {
+		p, err := a.AllocatePrefix(bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual = append(actual, p)
+	}
+	if expected := "10.0.1.0/24 10.0.0.128/25 10.0.2.0/23 10.0.0.64/26"; joinPrefixes(actual) != expected {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if p, err := a.AllocatePrefix(26); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected exhaustion, got %v %v", p, err)
+	}
+	if p, err := a.AllocatePrefix(21); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected exhaustion, got %v %v", p, err)
+	}
+	if n := a.Available(); n.Int64() != 63 {
+		t.Errorf("expected 63 available addresses, got %v", n)
+	}
+
+	if err := a.Release(netip.MustParsePrefix("10.0.2.0/23")); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AllocateSpecific(netip.MustParsePrefix("10.0.3.0/24")); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := a.AllocatePrefix(24); err != nil || p.String() != "10.0.2.0/24" {
+		t.Errorf("expected 10.0.2.0/24, got %v %v", p, err)
+	}
+}
+
+func TestAllocatorErrors(t *testing.T) {
+	if _, err := NewAllocator(netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("10.1.0.0/16")); err == nil {
+		t.Error("expected error for overlapping pools")
+	}
+	if _, err := NewAllocator(netip.Prefix{}); err == nil {
+		t.Error("expected error for invalid pool")
+	}
+
+	a, err := NewAllocator(netip.MustParsePrefix("10.0.0.0/24"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AllocateSpecific(netip.MustParsePrefix("10.0.0.0/25")); err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range []string{"10.0.0.64/26", "10.0.0.0/23", "10.0.1.0/24"} {
+		if err := a.AllocateSpecific(netip.MustParsePrefix(input)); err == nil {
+			t.Errorf("%s: expected error", input)
+		}
+		if err := a.Reserve(netip.MustParsePrefix(input)); err == nil {
+			t.Errorf("%s: expected error", input)
+		}
+	}
+	if err := a.Unreserve(netip.MustParsePrefix("10.0.0.0/25")); err == nil {
+		t.Error("expected error unreserving an allocation")
+	}
+	if err := a.Release(netip.MustParsePrefix("10.0.0.0/26")); err == nil {
+		t.Error("expected error releasing part of an allocation")
+	}
+}
+
+func TestAllocatorJSON(t *testing.T) {
+	a, err := NewAllocator(netip.MustParsePrefix("10.0.0.0/24"), netip.MustParsePrefix("2001:db8::/64"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Reserve(netip.MustParsePrefix("10.0.0.1/32")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.AllocateAddr(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.AllocatePrefix(64); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"version":1,"pools":["10.0.0.0/24","2001:db8::/64"],"allocated":["10.0.0.2/32","2001:db8::/64"],"reserved":["10.0.0.1/32"]}`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+
+	var b Allocator
+	if err := json.Unmarshal(data, &b); err != nil {
+		t.Fatal(err)
+	}
+	if addr, err := b.AllocateAddr(); err != nil || addr.String() != "10.0.0.3" {
+		t.Errorf("expected 10.0.0.3, got %v %v", addr, err)
+	}
+	if b.Available().Cmp(a.Available()) >= 0 {
+		t.Errorf("expected fewer than %v available addresses, got %v", a.Available(), b.Available())
+	}
+
+	for _, input := range []string{
+		`{"version":2,"pools":[]}`,
+		`{"version":1,"pools":["10.0.0.0/24"],"allocated":["10.0.1.0/32"]}`,
+		`{"version":1,"pools":["10.0.0.0/24"],"allocated":["10.0.0.0/25"],"reserved":["10.0.0.1/32"]}`,
+		`{"version":1,"pools":["10.0.0.0/24","10.0.0.0/25"]}`,
+	} {
+		var c Allocator
+		if err := json.Unmarshal([]byte(input), &c); err == nil {
+			t.Errorf("%s: expected error", input)
+		}
+	}
+}
+
+func TestAllocatorConcurrency(t *testing.T) {
+	a, err := NewAllocator(netip.MustParsePrefix("10.0.0.0/22"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		seen = map[netip.Addr]bool{}
+	)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				addr, err := a.AllocateAddr()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				if seen[addr] {
+					t.Errorf("%s allocated twice", addr)
+				}
+				seen[addr] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(seen) != 800 {
+		t.Errorf("expected 800 addresses, got %d", len(seen))
+	}
+}
+
+func TestAllocatorZeroValue(t *testing.T) {
+	var a Allocator
+	if addr, err := a.AllocateAddr(); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected exhaustion, got %v %v", addr, err)
+	}
+	if err := a.AddPool(netip.MustParsePrefix("10.0.0.0/31")); err != nil {
+		t.Fatal(err)
+	}
+	if addr, err := a.AllocateAddr(); err != nil || addr.String() != "10.0.0.0" {
+		t.Errorf("expected 10.0.0.0, got %v %v", addr, err)
+	}
+}
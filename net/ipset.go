@@ -0,0 +1,238 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// IPSet is an immutable set of IPv4 and IPv6 addresses. Internally it
+// is kept as a sorted list of non-overlapping, non-adjacent ranges, so
+// two sets holding the same addresses are always Equal. The zero value
+// is the empty set.
+type IPSet struct {
+	ranges []IPRange
+}
+
+// universe holds every IPv4 and every IPv6 address.
+var universe = []IPRange{
+	IPRangeFromPrefix(netip.MustParsePrefix("0.0.0.0/0")),
+	IPRangeFromPrefix(netip.MustParsePrefix("::/0")),
+}
+
+// NewIPSet returns the set of addresses covered by prefixes.
+func NewIPSet(prefixes ...netip.Prefix) (IPSet, error) {
+	ranges := make([]IPRange, 0, len(prefixes))
+	for _, p := range prefixes {
+		if !p.IsValid() {
+			return IPSet{}, fmt.Errorf("invalid prefix %s", p)
+		}
+		ranges = append(ranges, IPRangeFromPrefix(p))
+	}
+	return IPSet{normalize(ranges)}, nil
+}
+
+// NewIPSetFromRanges returns the set of addresses covered by ranges.
+func NewIPSetFromRanges(ranges ...IPRange) (IPSet, error) {
+	for _, r := range ranges {
+		if !r.IsValid() {
+			return IPSet{}, fmt.Errorf("invalid range %s-%s", r.From, r.To)
+		}
+	}
+	return IPSet{normalize(slices.Clone(ranges))}, nil
+}
+
+// normalize sorts ranges and merges any that overlap or abut. The
+// slice is modified in place.
+func normalize(ranges []IPRange) []IPRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+	slices.SortFunc(ranges, func(a, b IPRange) int {
+		return a.From.Compare(b.From)
+	})
+	out := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &out[len(out)-1]
+		if r.From.Is4() == last.To.Is4() && (!last.To.Less(r.From) || last.To.Next() == r.From) {
+			if last.To.Less(r.To) {
+				last.To = r.To
+			}
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// Ranges returns the normalized ranges making up s, in ascending
+// order with IPv4 before IPv6.
+func (s IPSet) Ranges() []IPRange {
+	return slices.Clone(s.ranges)
+}
+
+// Prefixes returns the minimal list of prefixes covering s, in
+// ascending order with IPv4 before IPv6.
+func (s IPSet) Prefixes() []netip.Prefix {
+	var out []netip.Prefix
+	for _, r := range s.ranges {
+		out = append(out, r.Prefixes()...)
+	}
+	return out
+}
+
+// IsEmpty reports whether s contains no addresses.
+func (s IPSet) IsEmpty() bool {
+	return len(s.ranges) == 0
+}
+
+// Equal reports whether s and o contain exactly the same addresses.
+func (s IPSet) Equal(o IPSet) bool {
+	return slices.Equal(s.ranges, o.ranges)
+}
+
+// Size returns the number of addresses in s, counted the same way as
+// Nipsv4 and Nipsv6.
+func (s IPSet) Size() *big.Int {
+	n := new(big.Int)
+	for _, r := range s.ranges {
+		n.Add(n, r.Size())
+	}
+	return n
+}
+
+// Union returns the set of addresses in s or o.
+func (s IPSet) Union(o IPSet) IPSet {
+	return IPSet{normalize(slices.Concat(s.ranges, o.ranges))}
+}
+
+// Intersect returns the set of addresses in both s and o.
+func (s IPSet) Intersect(o IPSet) IPSet {
+	var out []IPRange
+	a, b := s.ranges, o.ranges
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		from, to := a[i].From, a[i].To
+		if from.Less(b[j].From) {
+			from = b[j].From
+		}
+		if b[j].To.Less(to) {
+			to = b[j].To
+		}
+		if !to.Less(from) {
+			out = append(out, IPRange{From: from, To: to})
+		}
+		if a[i].To.Less(b[j].To) {
+			i++
+		} else {
+			j++
+		}
+	}
+	return IPSet{out}
+}
+
+// Difference returns the set of addresses in s but not in o.
+func (s IPSet) Difference(o IPSet) IPSet {
+	return IPSet{difference(s.ranges, o.ranges)}
+}
+
+// Complement returns the set of addresses, of either family, that are
+// not in s. Intersect the result with a set covering 0.0.0.0/0 or ::/0
+// to restrict it to a single family.
+func (s IPSet) Complement() IPSet {
+	return IPSet{difference(universe, s.ranges)}
+}
+
+// difference removes the normalized ranges b from the normalized
+// ranges a.
+func difference(a, b []IPRange) []IPRange {
+	var out []IPRange
+	j := 0
+	for _, r := range a {
+		for j < len(b) && b[j].To.Less(r.From) {
+			j++
+		}
+		from, done := r.From, false
+		for k := j; k < len(b) && !r.To.Less(b[k].From); k++ {
+			if from.Less(b[k].From) {
+				out = append(out, IPRange{From: from, To: b[k].From.Prev()})
+			}
+			if !b[k].To.Less(r.To) {
+				done = true
+				break
+			}
+			from = b[k].To.Next()
+		}
+		if !done {
+			out = append(out, IPRange{From: from, To: r.To})
+		}
+	}
+	return out
+}
+
+// search returns the index of the range in s containing addr, or -1.
+func (s IPSet) search(addr netip.Addr) int {
+	addr = addr.WithZone("")
+	i, _ := slices.BinarySearchFunc(s.ranges, addr, func(r IPRange, a netip.Addr) int {
+		return r.To.Compare(a)
+	})
+	if i < len(s.ranges) && s.ranges[i].Contains(addr) {
+		return i
+	}
+	return -1
+}
+
+// Contains reports whether addr is in s.
+func (s IPSet) Contains(addr netip.Addr) bool {
+	return addr.IsValid() && s.search(addr) >= 0
+}
+
+// ContainsRange reports whether every address in r is in s.
+func (s IPSet) ContainsRange(r IPRange) bool {
+	if !r.IsValid() {
+		return false
+	}
+	i := s.search(r.From)
+	return i >= 0 && !s.ranges[i].To.Less(r.To)
+}
+
+// ContainsPrefix reports whether every address in p is in s.
+func (s IPSet) ContainsPrefix(p netip.Prefix) bool {
+	return s.ContainsRange(IPRangeFromPrefix(p))
+}
+
+// ContainsSet reports whether every address in o is in s.
+func (s IPSet) ContainsSet(o IPSet) bool {
+	return len(difference(o.ranges, s.ranges)) == 0
+}
+
+// Overlaps reports whether s and o have any address in common.
+func (s IPSet) Overlaps(o IPSet) bool {
+	return !s.Intersect(o).IsEmpty()
+}
+
+// OverlapsRange reports whether any address in r is in s.
+func (s IPSet) OverlapsRange(r IPRange) bool {
+	if !r.IsValid() {
+		return false
+	}
+	return s.Overlaps(IPSet{[]IPRange{r}})
+}
+
+// OverlapsPrefix reports whether any address in p is in s.
+func (s IPSet) OverlapsPrefix(p netip.Prefix) bool {
+	return s.OverlapsRange(IPRangeFromPrefix(p))
+}
+
+// String returns the ranges of s as a comma-delimited list.
+func (s IPSet) String() string {
+	xs := make([]string, len(s.ranges))
+	for i, r := range s.ranges {
+		xs[i] = r.String()
+	}
+	return "{" + strings.Join(xs, ", ") + "}"
+}
@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"math/big"
+	"net/netip"
+	"testing"
+)
+
+func mustIPSet(t *testing.T, prefixes ...string) IPSet {
+	t.Helper()
+	ps := make([]netip.Prefix, len(prefixes))
+	for i, s := range prefixes {
+		ps[i] = netip.MustParsePrefix(s)
+	}
+	s, err := NewIPSet(ps...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestNewIPSet(t *testing.T) {
+	testpairs := []struct {
+		input    []string
+		expected string
+	}{
+		{input: nil, expected: "{}"},
+		{input: []string{"10.0.0.0/24", "10.0.1.0/24"}, expected: "{10.0.0.0-10.0.1.255}"},
+		{input: []string{"10.0.1.0/24", "10.0.0.0/16"}, expected: "{10.0.0.0-10.0.255.255}"},
+		{input: []string{"2001:db8::/32", "10.0.0.0/8"}, expected: "{10.0.0.0-10.255.255.255, 2001:db8::-2001:db8:ffff:ffff:ffff:ffff:ffff:ffff}"},
+		{input: []string{"255.255.255.0/24", "255.255.255.255/32", "::/128"}, expected: "{255.255.255.0-255.255.255.255, ::-::}"},
+	}
+
+	for _, pair := range testpairs {
+		actual := mustIPSet(t, pair.input...)
+		if actual.String() != pair.expected {
+			t.Errorf("%v: expected %v, got %v", pair.input, pair.expected, actual)
+		}
+	}
+
+	if _, err := NewIPSet(netip.Prefix{}); err == nil {
+		t.Error("expected error for invalid prefix")
+	}
+	if _, err := NewIPSetFromRanges(IPRange{}); err == nil {
+		t.Error("expected error for invalid range")
+	}
+}
+
+func TestIPSetAlgebra(t *testing.T) {
+	a := mustIPSet(t, "10.0.0.0/8", "2001:db8::/32")
+	b := mustIPSet(t, "10.128.0.0/9", "192.168.0.0/16", "2001:db8:8000::/33")
+
+	union := a.Union(b)
+	if expected := mustIPSet(t, "10.0.0.0/8", "192.168.0.0/16", "2001:db8::/32"); !union.Equal(expected) {
+		t.Errorf("union: expected %v, got %v", expected, union)
+	}
+
+	intersect := a.Intersect(b)
+	if expected := mustIPSet(t, "10.128.0.0/9", "2001:db8:8000::/33"); !intersect.Equal(expected) {
+		t.Errorf("intersect: expected %v, got %v", expected, intersect)
+	}
+
+	difference := a.Difference(b)
+	if expected := mustIPSet(t, "10.0.0.0/9", "2001:db8::/33"); !difference.Equal(expected) {
+		t.Errorf("difference: expected %v, got %v", expected, difference)
+	}
+
+	middle := mustIPSet(t, "10.1.0.0/16", "10.3.0.0/16")
+	if expected := mustIPSet(t, "10.0.0.0/16", "10.2.0.0/16", "10.4.0.0/14", "10.8.0.0/13", "10.16.0.0/12", "10.32.0.0/11", "10.64.0.0/10", "10.128.0.0/9", "2001:db8::/32"); !a.Difference(middle).Equal(expected) {
+		t.Errorf("difference: expected %v, got %v", expected, a.Difference(middle))
+	}
+
+	complement := a.Complement()
+	if complement.Overlaps(a) {
+		t.Errorf("complement %v overlaps %v", complement, a)
+	}
+	if expected := mustIPSet(t, "0.0.0.0/0", "::/0"); !complement.Union(a).Equal(expected) {
+		t.Errorf("complement: expected %v, got %v", expected, complement.Union(a))
+	}
+	if !complement.Complement().Equal(a) {
+		t.Errorf("double complement: expected %v, got %v", a, complement.Complement())
+	}
+	if !(IPSet{}).Complement().Complement().IsEmpty() {
+		t.Error("expected empty set")
+	}
+}
+
+func TestIPSetQueries(t *testing.T) {
+	s := mustIPSet(t, "10.0.0.0/8", "192.168.1.0/24", "2001:db8::/32")
+
+	for addr, expected := range map[string]bool{
+		"9.255.255.255":   false,
+		"10.0.0.0":        true,
+		"10.255.255.255":  true,
+		"192.168.1.77":    true,
+		"192.168.2.0":     false,
+		"2001:db8::1":     true,
+		"2001:db9::":      false,
+		"::ffff:10.0.0.1": false,
+	} {
+		if actual := s.Contains(netip.MustParseAddr(addr)); actual != expected {
+			t.Errorf("contains %s: expected %v, got %v", addr, expected, actual)
+		}
+	}
+
+	for prefix, expected := range map[string][2]bool{
+		"10.1.0.0/16":    {true, true},
+		"10.0.0.0/7":     {false, true},
+		"192.168.0.0/16": {false, true},
+		"172.16.0.0/12":  {false, false},
+		"2001:db8::/48":  {true, true},
+		"2001::/16":      {false, true},
+	} {
+		p := netip.MustParsePrefix(prefix)
+		if actual := s.ContainsPrefix(p); actual != expected[0] {
+			t.Errorf("contains %s: expected %v, got %v", prefix, expected[0], actual)
+		}
+		if actual := s.OverlapsPrefix(p); actual != expected[1] {
+			t.Errorf("overlaps %s: expected %v, got %v", prefix, expected[1], actual)
+		}
+	}
+
+	if !s.ContainsSet(mustIPSet(t, "10.1.0.0/16", "192.168.1.128/25")) {
+		t.Error("expected subset")
+	}
+	if s.ContainsSet(mustIPSet(t, "10.1.0.0/16", "192.168.0.0/23")) {
+		t.Error("expected not a subset")
+	}
+}
+
+func TestIPSetSize(t *testing.T) {
+	s := mustIPSet(t, "10.0.0.0/8", "10.1.0.0/16", "192.168.1.0/24", "2001:db8::/64")
+	expected := new(big.Int)
+	for _, input := range []string{"10.0.0.0/8", "192.168.1.0/24", "2001:db8::/64"} {
+		n, err := NipsPrefix(netip.MustParsePrefix(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected.Add(expected, n)
+	}
+	if actual := s.Size(); actual.Cmp(expected) != 0 {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if actual := (IPSet{}).Size(); actual.Sign() != 0 {
+		t.Errorf("expected 0, got %v", actual)
+	}
+}
+
+func TestIPSetPrefixes(t *testing.T) {
+	s := mustIPSet(t, "10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/25", "2001:db8::/33", "2001:db8:8000::/33")
+	var actual []string
+	for _, p := range s.Prefixes() {
+		actual = append(actual, p.String())
+	}
+	expected := []string{"10.0.0.0/23", "10.0.2.0/25", "2001:db8::/32"}
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, actual)
+			break
+		}
+	}
+}
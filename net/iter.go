@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"net/netip"
+)
+
+// AllAddrs returns an iterator over every address in p, in ascending
+// order. Nothing is materialized, so even ::/0 may be walked until the
+// caller stops.
+func AllAddrs(p netip.Prefix) (iter.Seq[netip.Addr], error) {
+	if !p.IsValid() {
+		return nil, errors.New("invalid netip.Prefix")
+	}
+	p = p.Masked()
+	return addrSeq(firstAddr(p), lastAddr(p)), nil
+}
+
+// AllHosts returns an iterator over the usable host addresses of p, in
+// ascending order. The usable range is that reported by NewSubnetInfo.
+func AllHosts(p netip.Prefix) (iter.Seq[netip.Addr], error) {
+	si, err := NewSubnetInfo(p)
+	if err != nil {
+		return nil, err
+	}
+	return addrSeq(si.First, si.Last), nil
+}
+
+// addrSeq returns an iterator over the addresses from first to last
+// inclusive.
+func addrSeq(first, last netip.Addr) iter.Seq[netip.Addr] {
+	return func(yield func(netip.Addr) bool) {
+		for a := first; a.IsValid() && !last.Less(a); a = a.Next() {
+			if !yield(a) {
+				return
+			}
+		}
+	}
+}
+
+// AllSubnets returns an iterator over the subnets of length bits within
+// p, in ascending order. Unlike Subnets, it places no limit on how many
+// subnets there may be.
+func AllSubnets(p netip.Prefix, bits int) (iter.Seq[netip.Prefix], error) {
+	if !p.IsValid() {
+		return nil, errors.New("invalid netip.Prefix")
+	}
+	if bits < p.Bits() || bits > p.Addr().BitLen() {
+		return nil, fmt.Errorf("cannot divide %s into /%d subnets", p, bits)
+	}
+	p = p.Masked()
+	is4 := p.Addr().Is4()
+	mask := hostMask(p.Addr().BitLen() - bits)
+	end := u128(lastAddr(p))
+	return func(yield func(netip.Prefix) bool) {
+		cur := u128(p.Addr())
+		for {
+			if !yield(netip.PrefixFrom(cur.addr(is4), bits)) {
+				return
+			}
+			last := cur.or(mask)
+			if last == end {
+				return
+			}
+			cur, _ = last.add(uint128{0, 1})
+		}
+	}, nil
+}
@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestAllAddrs(t *testing.T) {
+	testpairs := []struct {
+		prefix   string
+		expected []string
+	}{
+		{prefix: "192.0.2.5/30", expected: []string{"192.0.2.4", "192.0.2.5", "192.0.2.6", "192.0.2.7"}},
+		{prefix: "192.0.2.1/32", expected: []string{"192.0.2.1"}},
+		{prefix: "255.255.255.254/31", expected: []string{"255.255.255.254", "255.255.255.255"}},
+		{prefix: "2001:db8::/126", expected: []string{"2001:db8::", "2001:db8::1", "2001:db8::2", "2001:db8::3"}},
+		{prefix: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe/127", expected: []string{"ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"}},
+	}
+
+	for _, pair := range testpairs {
+		seq, err := AllAddrs(netip.MustParsePrefix(pair.prefix))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var actual []string
+		for a := range seq {
+			actual = append(actual, a.String())
+		}
+		if !slices.Equal(actual, pair.expected) {
+			t.Errorf("%s: expected %v, got %v", pair.prefix, pair.expected, actual)
+		}
+	}
+
+	if _, err := AllAddrs(netip.Prefix{}); err == nil {
+		t.Error("expected error for invalid prefix")
+	}
+}
+
+func TestAllAddrsEarlyTermination(t *testing.T) {
+	// Walking the whole IPv6 space would never finish, so this only
+	// passes if iteration stops when the loop does.
+	seq, err := AllAddrs(netip.MustParsePrefix("::/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual []netip.Addr
+	for a := range seq {
+		if len(actual) == 3 {
+			break
+		}
+		actual = append(actual, a)
+	}
+	expected := []netip.Addr{netip.MustParseAddr("::"), netip.MustParseAddr("::1"), netip.MustParseAddr("::2")}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestAllHosts(t *testing.T) {
+	testpairs := []struct {
+		prefix   string
+		expected []string
+	}{
+		{prefix: "192.0.2.0/30", expected: []string{"192.0.2.1", "192.0.2.2"}},
+		{prefix: "192.0.2.0/31", expected: []string{"192.0.2.0", "192.0.2.1"}},
+		{prefix: "192.0.2.1/32", expected: []string{"192.0.2.1"}},
+		{prefix: "2001:db8::/126", expected: []string{"2001:db8::1", "2001:db8::2", "2001:db8::3"}},
+		{prefix: "2001:db8::/127", expected: []string{"2001:db8::", "2001:db8::1"}},
+	}
+
+	for _, pair := range testpairs {
+		seq, err := AllHosts(netip.MustParsePrefix(pair.prefix))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var actual []string
+		for a := range seq {
+			actual = append(actual, a.String())
+		}
+		if !slices.Equal(actual, pair.expected) {
+			t.Errorf("%s: expected %v, got %v", pair.prefix, pair.expected, actual)
+		}
+	}
+
+	// The hosts of a /8 are streamed, not collected.
+	seq, err := AllHosts(netip.MustParsePrefix("10.0.0.0/8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for a := range seq {
+		if a != netip.MustParseAddr("10.0.0.1") {
+			t.Errorf("expected 10.0.0.1, got %v", a)
+		}
+		break
+	}
+}
+
+func TestAllSubnets(t *testing.T) {
+	testpairs := []struct {
+		prefix   string
+		bits     int
+		expected string
+	}{
+		{prefix: "192.0.2.0/24", bits: 26, expected: "192.0.2.0/26 192.0.2.64/26 192.0.2.128/26 192.0.2.192/26"},
+		{prefix: "192.0.2.77/24", bits: 24, expected: "192.0.2.0/24"},
+		{prefix: "255.255.255.0/24", bits: 25, expected: "255.255.255.0/25 255.255.255.128/25"},
+		{prefix: "0.0.0.0/0", bits: 2, expected: "0.0.0.0/2 64.0.0.0/2 128.0.0.0/2 192.0.0.0/2"},
+		{prefix: "2001:db8::/46", bits: 48, expected: "2001:db8::/48 2001:db8:1::/48 2001:db8:2::/48 2001:db8:3::/48"},
+		{prefix: "::/0", bits: 1, expected: "::/1 8000::/1"},
+	}
+
+	for _, pair := range testpairs {
+		seq, err := AllSubnets(netip.MustParsePrefix(pair.prefix), pair.bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := joinPrefixes(slices.Collect(seq))
+		if actual != pair.expected {
+			t.Errorf("%s /%d: expected %s, got %s", pair.prefix, pair.bits, pair.expected, actual)
+		}
+	}
+
+	// A split far beyond the limit imposed by Subnets can be streamed.
+	seq, err := AllSubnets(netip.MustParsePrefix("2001:db8::/32"), 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for p := range seq {
+		if n == 1000 {
+			if p != netip.MustParsePrefix("2001:db8::3e8/128") {
+				t.Errorf("expected 2001:db8::3e8/128, got %v", p)
+			}
+			break
+		}
+		n++
+	}
+
+	for _, bits := range []int{23, 33} {
+		if _, err := AllSubnets(netip.MustParsePrefix("192.0.2.0/24"), bits); err == nil {
+			t.Errorf("/%d: expected error", bits)
+		}
+	}
+	if _, err := AllSubnets(netip.Prefix{}, 0); err == nil {
+		t.Error("expected error for invalid prefix")
+	}
+}
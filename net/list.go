@@ -0,0 +1,225 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/netip"
+	"strings"
+)
+
+// ListError reports a malformed line in an address list.
+type ListError struct {
+	Line int    // the 1-based line number
+	Text string // the offending entry, without any comment
+	Err  error  // the underlying error
+}
+
+func (e *ListError) Error() string {
+	return fmt.Sprintf("line %d: %q: %v", e.Line, e.Text, e.Err)
+}
+
+func (e *ListError) Unwrap() error {
+	return e.Err
+}
+
+// ListEntry is one entry of an address list.
+type ListEntry struct {
+	Line     int            // the 1-based line number
+	Prefixes []netip.Prefix // the masked prefixes the entry denotes
+}
+
+// ListReader reads allowlists, blocklists and similar address lists
+// one entry per line. Each line holds one of
+//
+//	192.0.2.1                    a single address
+//	192.0.2.0/24                 a CIDR prefix
+//	192.0.2.0/255.255.255.0      an IPv4 prefix with a netmask
+//	10.0.0.0 255.0.0.0           likewise, separated by white space
+//	192.0.2.10-192.0.2.20        an inclusive range, spaces optional
+//
+// and may mix IPv4 and IPv6. Blank lines and comments, introduced by
+// "#" or "//" and running to the end of the line, are skipped.
+type ListReader struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+// NewListReader returns a ListReader reading from r.
+func NewListReader(r io.Reader) *ListReader {
+	return &ListReader{sc: bufio.NewScanner(r)}
+}
+
+// Next returns the next entry. It returns io.EOF when the input is
+// exhausted, and a *ListError for a malformed line, after which
+// reading may continue.
+func (lr *ListReader) Next() (ListEntry, error) {
+	for lr.sc.Scan() {
+		lr.line++
+		text := stripComment(lr.sc.Text())
+		if text == "" {
+			continue
+		}
+		prefixes, err := parseListEntry(text)
+		if err != nil {
+			return ListEntry{}, &ListError{Line: lr.line, Text: text, Err: err}
+		}
+		return ListEntry{Line: lr.line, Prefixes: prefixes}, nil
+	}
+	if err := lr.sc.Err(); err != nil {
+		return ListEntry{}, err
+	}
+	return ListEntry{}, io.EOF
+}
+
+// stripComment removes any comment and surrounding white space from
+// line.
+func stripComment(line string) string {
+	for _, marker := range []string{"#", "//"} {
+		if i := strings.Index(line, marker); i >= 0 {
+			line = line[:i]
+		}
+	}
+	return strings.TrimSpace(line)
+}
+
+// parseListEntry parses one list entry.
+func parseListEntry(text string) ([]netip.Prefix, error) {
+	if from, to, ok := strings.Cut(text, "-"); ok {
+		r, err := parseListRange(strings.TrimSpace(from), strings.TrimSpace(to))
+		if err != nil {
+			return nil, err
+		}
+		return r.Prefixes(), nil
+	}
+
+	var p netip.Prefix
+	switch fields := strings.Fields(text); {
+	case len(fields) == 2:
+		var err error
+		if p, err = parseNetmaskEntry(fields[0], fields[1]); err != nil {
+			return nil, err
+		}
+	case len(fields) != 1:
+		return nil, errors.New("too many fields")
+	case strings.Contains(text, "/"):
+		addr, bits, _ := strings.Cut(text, "/")
+		var err error
+		if strings.Contains(bits, ".") {
+			p, err = parseNetmaskEntry(addr, bits)
+		} else {
+			p, err = netip.ParsePrefix(text)
+		}
+		if err != nil {
+			return nil, err
+		}
+	default:
+		addr, err := parseListAddr(text)
+		if err != nil {
+			return nil, err
+		}
+		p = netip.PrefixFrom(addr, addr.BitLen())
+	}
+	return []netip.Prefix{p.Masked()}, nil
+}
+
+// parseListAddr parses an address, which must not carry a zone.
+func parseListAddr(s string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if addr.Zone() != "" {
+		return netip.Addr{}, fmt.Errorf("%s: zones are not allowed", s)
+	}
+	return addr, nil
+}
+
+// parseListRange parses the bounds of an inclusive range.
+func parseListRange(from, to string) (IPRange, error) {
+	a, err := parseListAddr(from)
+	if err != nil {
+		return IPRange{}, err
+	}
+	b, err := parseListAddr(to)
+	if err != nil {
+		return IPRange{}, err
+	}
+	return NewIPRange(a, b)
+}
+
+// parseNetmaskEntry parses an IPv4 address and a dotted netmask.
+func parseNetmaskEntry(addr, mask string) (netip.Prefix, error) {
+	a, err := parseListAddr(addr)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	m, err := netip.ParseAddr(mask)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	if !a.Is4() || !m.Is4() {
+		return netip.Prefix{}, errors.New("netmask notation is only supported for IPv4")
+	}
+	bits, err := NetmaskLen(m)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(a, bits), nil
+}
+
+// ReadList reads an entire address list from r, returning the prefixes
+// of every entry in the order they appear. It stops at the first
+// malformed line.
+func ReadList(r io.Reader) ([]netip.Prefix, error) {
+	lr := NewListReader(r)
+	var out []netip.Prefix
+	for {
+		e, err := lr.Next()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e.Prefixes...)
+	}
+}
+
+// ListCoverage summarizes the addresses an address list covers.
+type ListCoverage struct {
+	Prefixes []netip.Prefix // the aggregated prefixes
+	IPv4     uint64         // the number of IPv4 addresses covered
+	IPv6     *big.Int       // the number of IPv6 addresses covered
+}
+
+// NewListCoverage aggregates prefixes, which may overlap, and counts
+// the addresses they cover. Overlapping entries are counted once.
+func NewListCoverage(prefixes ...netip.Prefix) (*ListCoverage, error) {
+	agg, err := Aggregate(prefixes...)
+	if err != nil {
+		return nil, err
+	}
+	c := &ListCoverage{Prefixes: agg, IPv6: new(big.Int)}
+	for _, p := range agg {
+		if p.Addr().Is4() {
+			n, err := Nipsv4Prefix(p)
+			if err != nil {
+				return nil, err
+			}
+			c.IPv4 += n
+			continue
+		}
+		n, err := Nipsv6Prefix(p)
+		if err != nil {
+			return nil, err
+		}
+		c.IPv6.Add(c.IPv6, n)
+	}
+	return c, nil
+}
@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+const sampleList = `# Office allowlist
+192.0.2.1
+192.0.2.0/24   # overlaps the line above
+
+10.0.0.0 255.0.0.0
+172.16.0.0/255.240.0.0
+198.51.100.10 - 198.51.100.20
+// IPv6 entries
+2001:db8::/32
+2001:db8:1::1-2001:db8:1::4
+`
+
+func TestListReader(t *testing.T) {
+	lr := NewListReader(strings.NewReader(sampleList))
+	testpairs := []struct {
+		line     int
+		expected string
+	}{
+		{line: 2, expected: "192.0.2.1/32"},
+		{line: 3, expected: "192.0.2.0/24"},
+		{line: 5, expected: "10.0.0.0/8"},
+		{line: 6, expected: "172.16.0.0/12"},
+		{line: 7, expected: "198.51.100.10/31 198.51.100.12/30 198.51.100.16/30 198.51.100.20/32"},
+		{line: 9, expected: "2001:db8::/32"},
+		{line: 10, expected: "2001:db8:1::1/128 2001:db8:1::2/127 2001:db8:1::4/128"},
+	}
+
+	for _, pair := range testpairs {
+		e, err := lr.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Line != pair.line {
+			t.Errorf("expected line %d, got %d", pair.line, e.Line)
+		}
+		if actual := joinPrefixes(e.Prefixes); actual != pair.expected {
+			t.Errorf("line %d: expected %s, got %s", pair.line, pair.expected, actual)
+		}
+	}
+	if _, err := lr.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestListReaderErrors(t *testing.T) {
+	testpairs := []struct {
+		input string
+		line  int
+	}{
+		{input: "192.0.2.1\n192.0.2.256\n", line: 2},
+		{input: "\n\n192.0.2.0/33\n", line: 3},
+		{input: "192.0.2.0 255.0.255.0", line: 1},
+		{input: "2001:db8:: ffff::", line: 1},
+		{input: "192.0.2.20-192.0.2.10", line: 1},
+		{input: "192.0.2.1-2001:db8::1", line: 1},
+		{input: "fe80::1%eth0", line: 1},
+		{input: "192.0.2.1 192.0.2.2 192.0.2.3", line: 1},
+		{input: "# comment\nexample.com # not an address", line: 2},
+	}
+
+	for _, pair := range testpairs {
+		lr := NewListReader(strings.NewReader(pair.input))
+		_, err := lr.Next()
+		for err == nil {
+			_, err = lr.Next()
+		}
+		var le *ListError
+		if !errors.As(err, &le) {
+			t.Errorf("%q: expected *ListError, got %v", pair.input, err)
+			continue
+		}
+		if le.Line != pair.line {
+			t.Errorf("%q: expected line %d, got %d", pair.input, pair.line, le.Line)
+		}
+	}
+
+	// Reading continues after a malformed line.
+	lr := NewListReader(strings.NewReader("bogus\n192.0.2.1\n"))
+	if _, err := lr.Next(); err == nil {
+		t.Fatal("expected error")
+	}
+	if e, err := lr.Next(); err != nil || e.Line != 2 {
+		t.Errorf("expected line 2, got %v (%v)", e, err)
+	}
+}
+
+func TestReadList(t *testing.T) {
+	prefixes, err := ReadList(strings.NewReader(sampleList))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prefixes) != 12 {
+		t.Errorf("expected 12 prefixes, got %d: %s", len(prefixes), joinPrefixes(prefixes))
+	}
+
+	if _, err := ReadList(strings.NewReader("192.0.2.1\nbogus\n")); err == nil {
+		t.Error("expected error")
+	} else if err.Error() != `line 2: "bogus": ParseAddr("bogus"): unable to parse IP` {
+		t.Errorf("unexpected error %q", err)
+	}
+}
+
+func TestListCoverage(t *testing.T) {
+	prefixes, err := ReadList(strings.NewReader(sampleList))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewListCoverage(prefixes...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 192.0.2.0/24, 10.0.0.0/8, 172.16.0.0/12 and 11 addresses.
+	if c.IPv4 != 256+16777216+1048576+11 {
+		t.Errorf("expected %d IPv4 addresses, got %d", 256+16777216+1048576+11, c.IPv4)
+	}
+	if c.IPv6.String() != "79228162514264337593543950336" {
+		t.Errorf("expected 79228162514264337593543950336 IPv6 addresses, got %s", c.IPv6)
+	}
+	expected := "10.0.0.0/8 172.16.0.0/12 192.0.2.0/24 198.51.100.10/31 198.51.100.12/30 198.51.100.16/30 198.51.100.20/32 2001:db8::/32"
+	if actual := joinPrefixes(c.Prefixes); actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+
+	c, err = NewListCoverage(parsePrefixes(t, "0.0.0.0/0", "::ffff:0:0/96")...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.IPv4 != 1<<32 || c.IPv6.String() != "4294967296" {
+		t.Errorf("expected 4294967296 and 4294967296, got %d and %s", c.IPv4, c.IPv6)
+	}
+}
@@ -0,0 +1,204 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// MAC is an IEEE 802 MAC-48 (EUI-48) address. Unlike net.HardwareAddr
+// it is comparable and may be used as a map key.
+type MAC [6]byte
+
+// MACNotation identifies how a MAC address is written.
+type MACNotation int
+
+const (
+	// MACColon is colon-separated octets, e.g. 00:00:5e:00:53:01.
+	MACColon MACNotation = iota
+	// MACHyphen is hyphen-separated octets, e.g. 00-00-5e-00-53-01.
+	MACHyphen
+	// MACDotted is Cisco-style dot-separated groups of four digits,
+	// e.g. 0000.5e00.5301.
+	MACDotted
+	// MACBare is twelve hexadecimal digits without separators, e.g.
+	// 00005e005301.
+	MACBare
+)
+
+// String returns the name of n.
+func (n MACNotation) String() string {
+	switch n {
+	case MACColon:
+		return "colon"
+	case MACHyphen:
+		return "hyphen"
+	case MACDotted:
+		return "dotted"
+	case MACBare:
+		return "bare"
+	default:
+		return "MACNotation(" + strconv.Itoa(int(n)) + ")"
+	}
+}
+
+// ParseMAC parses s as a MAC-48 address in any of the notations of
+// MACNotation. Hexadecimal digits may be upper or lower case.
+func ParseMAC(s string) (MAC, error) {
+	m, _, err := ParseMACNotation(s)
+	return m, err
+}
+
+// ParseMACNotation parses s as ParseMAC does and reports the notation
+// it was written in.
+func ParseMACNotation(s string) (MAC, MACNotation, error) {
+	var (
+		digits string
+		n      MACNotation
+	)
+	switch {
+	case len(s) == 17 && strings.Count(s, ":") == 5:
+		digits, n = splitMAC(s, ':', 2), MACColon
+	case len(s) == 17 && strings.Count(s, "-") == 5:
+		digits, n = splitMAC(s, '-', 2), MACHyphen
+	case len(s) == 14 && strings.Count(s, ".") == 2:
+		digits, n = splitMAC(s, '.', 4), MACDotted
+	case len(s) == 12:
+		digits, n = s, MACBare
+	}
+	var m MAC
+	if len(digits) != 2*len(m) {
+		return MAC{}, 0, fmt.Errorf("invalid MAC address %q", s)
+	}
+	for i := range m {
+		v, err := strconv.ParseUint(digits[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return MAC{}, 0, fmt.Errorf("invalid MAC address %q", s)
+		}
+		m[i] = byte(v)
+	}
+	return m, n, nil
+}
+
+// splitMAC removes the separator sep from s, which must occur after
+// every group of width digits. It returns "" if it does not.
+func splitMAC(s string, sep byte, width int) string {
+	var sb strings.Builder
+	for _, group := range strings.Split(s, string(sep)) {
+		if len(group) != width {
+			return ""
+		}
+		sb.WriteString(group)
+	}
+	return sb.String()
+}
+
+// MACFromHardwareAddr converts hw to a MAC. It fails unless hw is a
+// 6-byte address.
+func MACFromHardwareAddr(hw net.HardwareAddr) (MAC, error) {
+	if len(hw) != 6 {
+		return MAC{}, fmt.Errorf("%s is not a MAC-48 address", hw)
+	}
+	return MAC(hw), nil
+}
+
+// HardwareAddr returns m as a net.HardwareAddr.
+func (m MAC) HardwareAddr() net.HardwareAddr {
+	return net.HardwareAddr(m[:])
+}
+
+// String returns m in lowercase colon notation.
+func (m MAC) String() string {
+	return m.Format(MACColon)
+}
+
+// Format returns m written in notation n, using lowercase hexadecimal
+// digits.
+func (m MAC) Format(n MACNotation) string {
+	var (
+		sep   byte
+		width int
+	)
+	switch n {
+	case MACHyphen:
+		sep, width = '-', 1
+	case MACDotted:
+		sep, width = '.', 2
+	case MACBare:
+	default:
+		sep, width = ':', 1
+	}
+	var sb strings.Builder
+	for i, b := range m {
+		if sep != 0 && i > 0 && i%width == 0 {
+			sb.WriteByte(sep)
+		}
+		sb.WriteByte(hexDigits[b>>4])
+		sb.WriteByte(hexDigits[b&0xf])
+	}
+	return sb.String()
+}
+
+// OUI returns the Organizationally Unique Identifier of m, its first
+// three octets.
+func (m MAC) OUI() [3]byte {
+	return [3]byte(m[:3])
+}
+
+// IsMulticast reports whether m is a group address, that is, whether
+// its individual/group bit is set.
+func (m MAC) IsMulticast() bool {
+	return m[0]&0x01 != 0
+}
+
+// IsLocal reports whether m is locally administered, that is, whether
+// its universal/local bit is set.
+func (m MAC) IsLocal() bool {
+	return m[0]&0x02 != 0
+}
+
+// IsBroadcast reports whether m is the broadcast address
+// ff:ff:ff:ff:ff:ff.
+func (m MAC) IsBroadcast() bool {
+	return m == MAC{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+}
+
+// EUI64 returns the modified EUI-64 interface identifier derived from
+// m as described in RFC 4291, appendix A: ff:fe is inserted between the
+// OUI and the rest of the address, and the universal/local bit is
+// inverted.
+func (m MAC) EUI64() [8]byte {
+	return [8]byte{m[0] ^ 0x02, m[1], m[2], 0xff, 0xfe, m[3], m[4], m[5]}
+}
+
+// SLAACAddr returns the stateless autoconfiguration address that an
+// interface with MAC address m forms within the /64 prefix p, using its
+// modified EUI-64 interface identifier.
+func SLAACAddr(p netip.Prefix, m MAC) (netip.Addr, error) {
+	if !p.IsValid() || !p.Addr().Is6() || p.Addr().Is4In6() || p.Bits() != 64 {
+		return netip.Addr{}, fmt.Errorf("%s is not an IPv6 /64 prefix", p)
+	}
+	b := p.Masked().Addr().As16()
+	iid := m.EUI64()
+	copy(b[8:], iid[:])
+	return netip.AddrFrom16(b), nil
+}
+
+// MACFromEUI64Addr recovers the MAC address from which the modified
+// EUI-64 interface identifier of addr was derived. It fails unless the
+// identifier contains the ff:fe marker.
+func MACFromEUI64Addr(addr netip.Addr) (MAC, error) {
+	if !addr.Is6() || addr.Is4In6() {
+		return MAC{}, fmt.Errorf("%s is not an IPv6 address", addr)
+	}
+	b := addr.As16()
+	if b[11] != 0xff || b[12] != 0xfe {
+		return MAC{}, fmt.Errorf("%s does not have an EUI-64 interface identifier", addr)
+	}
+	return MAC{b[8] ^ 0x02, b[9], b[10], b[13], b[14], b[15]}, nil
+}
@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestParseMAC(t *testing.T) {
+	expected := MAC{0x00, 0x00, 0x5e, 0x00, 0x53, 0xab}
+	testpairs := []struct {
+		input    string
+		notation MACNotation
+	}{
+		{input: "00:00:5e:00:53:ab", notation: MACColon},
+		{input: "00:00:5E:00:53:AB", notation: MACColon},
+		{input: "00-00-5e-00-53-ab", notation: MACHyphen},
+		{input: "0000.5e00.53ab", notation: MACDotted},
+		{input: "00005e0053AB", notation: MACBare},
+	}
+
+	for _, pair := range testpairs {
+		actual, notation, err := ParseMACNotation(pair.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Errorf("%s: expected %v, got %v", pair.input, expected, actual)
+		}
+		if notation != pair.notation {
+			t.Errorf("%s: expected %v, got %v", pair.input, pair.notation, notation)
+		}
+	}
+}
+
+func TestParseMACErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"00:00:5e:00:53",
+		"00:00:5e:00:53:ab:cd",
+		"00:00:5e-00:53:ab",
+		"0:00:5e:00:53:abc",
+		"00:00:5e:00:53:zz",
+		"000.05e00.53ab",
+		"00005e0053ag",
+		"+0005e0053ab",
+	} {
+		if actual, err := ParseMAC(s); err == nil {
+			t.Errorf("%q: expected error, got %v", s, actual)
+		}
+	}
+}
+
+func TestMACFormat(t *testing.T) {
+	m := MAC{0x00, 0x00, 0x5e, 0x00, 0x53, 0xab}
+	testpairs := []struct {
+		notation MACNotation
+		expected string
+	}{
+		{notation: MACColon, expected: "00:00:5e:00:53:ab"},
+		{notation: MACHyphen, expected: "00-00-5e-00-53-ab"},
+		{notation: MACDotted, expected: "0000.5e00.53ab"},
+		{notation: MACBare, expected: "00005e0053ab"},
+	}
+
+	for _, pair := range testpairs {
+		actual := m.Format(pair.notation)
+		if actual != pair.expected {
+			t.Errorf("%v: expected %s, got %s", pair.notation, pair.expected, actual)
+		}
+		if back, err := ParseMAC(actual); err != nil || back != m {
+			t.Errorf("%s: expected %v, got %v (%v)", actual, m, back, err)
+		}
+	}
+	if m.String() != "00:00:5e:00:53:ab" {
+		t.Errorf("expected 00:00:5e:00:53:ab, got %s", m)
+	}
+}
+
+func TestMACBits(t *testing.T) {
+	testpairs := []struct {
+		mac       string
+		multicast bool
+		local     bool
+		broadcast bool
+	}{
+		{mac: "00:00:5e:00:53:01"},
+		{mac: "01:00:5e:00:00:fb", multicast: true},
+		{mac: "02:00:5e:10:00:01", local: true},
+		{mac: "33:33:00:00:00:01", multicast: true, local: true},
+		{mac: "ff:ff:ff:ff:ff:ff", multicast: true, local: true, broadcast: true},
+	}
+
+	for _, pair := range testpairs {
+		m, err := ParseMAC(pair.mac)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.IsMulticast() != pair.multicast {
+			t.Errorf("%s: expected multicast %v", m, pair.multicast)
+		}
+		if m.IsLocal() != pair.local {
+			t.Errorf("%s: expected local %v", m, pair.local)
+		}
+		if m.IsBroadcast() != pair.broadcast {
+			t.Errorf("%s: expected broadcast %v", m, pair.broadcast)
+		}
+	}
+
+	if oui := (MAC{0x00, 0x00, 0x5e, 0x00, 0x53, 0x01}).OUI(); oui != [3]byte{0x00, 0x00, 0x5e} {
+		t.Errorf("expected 00:00:5e, got %x", oui)
+	}
+}
+
+func TestMACHardwareAddr(t *testing.T) {
+	hw, err := net.ParseMAC("00:00:5e:00:53:01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := MACFromHardwareAddr(hw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.HardwareAddr().String() != hw.String() {
+		t.Errorf("expected %v, got %v", hw, m.HardwareAddr())
+	}
+
+	hw, err = net.ParseMAC("02:00:5e:10:00:00:00:01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, err := MACFromHardwareAddr(hw); err == nil {
+		t.Errorf("expected error, got %v", actual)
+	}
+}
+
+func TestSLAAC(t *testing.T) {
+	testpairs := []struct {
+		mac      string
+		prefix   string
+		expected string
+	}{
+		// The example from RFC 4291, appendix A, applied to a /64.
+		{mac: "00:00:5e:00:53:01", prefix: "2001:db8::/64", expected: "2001:db8::200:5eff:fe00:5301"},
+		{mac: "02:00:5e:10:00:01", prefix: "fe80::/64", expected: "fe80::5eff:fe10:1"},
+		{mac: "3c:22:fb:12:34:56", prefix: "2001:db8:1:2:ffff::/64", expected: "2001:db8:1:2:3e22:fbff:fe12:3456"},
+	}
+
+	for _, pair := range testpairs {
+		m, err := ParseMAC(pair.mac)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := SLAACAddr(netip.MustParsePrefix(pair.prefix), m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.String() != pair.expected {
+			t.Errorf("%s %s: expected %s, got %v", pair.prefix, m, pair.expected, actual)
+		}
+		back, err := MACFromEUI64Addr(actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if back != m {
+			t.Errorf("%s: expected %v, got %v", actual, m, back)
+		}
+	}
+}
+
+func TestSLAACErrors(t *testing.T) {
+	m := MAC{0x00, 0x00, 0x5e, 0x00, 0x53, 0x01}
+	for _, p := range []string{"2001:db8::/48", "10.0.0.0/8", "::ffff:0:0/64"} {
+		if actual, err := SLAACAddr(netip.MustParsePrefix(p), m); err == nil {
+			t.Errorf("%s: expected error, got %v", p, actual)
+		}
+	}
+	for _, a := range []string{"2001:db8::1", "192.0.2.1", "::ffff:192.0.2.1"} {
+		if actual, err := MACFromEUI64Addr(netip.MustParseAddr(a)); err == nil {
+			t.Errorf("%s: expected error, got %v", a, actual)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"net"
+	"net/netip"
+)
+
+// maskOnes returns the number of leading one bits in the width-bit
+// integer u, and the position, counting from the most significant bit,
+// of the first one bit that follows a zero bit. That position is -1 if
+// u is a contiguous mask.
+func maskOnes(u uint128, width int) (ones, stray int) {
+	for ones < width && u.bit(ones, width) == 1 {
+		ones++
+	}
+	for i := ones + 1; i < width; i++ {
+		if u.bit(i, width) == 1 {
+			return ones, i
+		}
+	}
+	return ones, -1
+}
+
+// netmaskLen returns the prefix length of the netmask m, which must
+// consist of leading one bits only.
+func netmaskLen(m netip.Addr) (int, error) {
+	ones, stray := maskOnes(u128(m), m.BitLen())
+	if stray >= 0 {
+		return 0, fmt.Errorf("%s is not a contiguous netmask: bit %d is set but bit %d is not", m, stray, ones)
+	}
+	return ones, nil
+}
+
+// checkMask validates the net.IPMask m, returning its prefix length
+// and width in bits. Unlike m.Size, it explains why a mask is rejected.
+func checkMask(m net.IPMask) (ones, width int, err error) {
+	addr, ok := netip.AddrFromSlice(m)
+	if !ok {
+		return 0, 0, fmt.Errorf("%s is not a valid mask: it is %d bytes long", m, len(m))
+	}
+	if ones, err = netmaskLen(addr); err != nil {
+		return 0, 0, err
+	}
+	return ones, addr.BitLen(), nil
+}
+
+// maskWidth returns the width of the addresses of the given family.
+func maskWidth(is4 bool) int {
+	if is4 {
+		return 8 * net.IPv4len
+	}
+	return 8 * net.IPv6len
+}
+
+// NetmaskFromLen returns the netmask for a prefix of length bits, e.g.
+// 255.255.255.0 for an IPv4 /24.
+func NetmaskFromLen(bits int, is4 bool) (netip.Addr, error) {
+	width := maskWidth(is4)
+	if bits < 0 || bits > width {
+		return netip.Addr{}, fmt.Errorf("invalid prefix length %d", bits)
+	}
+	return hostMask(width - bits).xor(hostMask(width)).addr(is4), nil
+}
+
+// NetmaskLen returns the prefix length of the netmask m. A mask whose
+// one bits are not contiguous, such as 255.0.255.0, is rejected.
+func NetmaskLen(m netip.Addr) (int, error) {
+	if !m.IsValid() {
+		return 0, errors.New("invalid netmask")
+	}
+	return netmaskLen(m)
+}
+
+// WildcardFromLen returns the wildcard (inverse) mask for a prefix of
+// length bits, as used in Cisco ACLs, e.g. 0.0.0.255 for an IPv4 /24.
+func WildcardFromLen(bits int, is4 bool) (netip.Addr, error) {
+	m, err := NetmaskFromLen(bits, is4)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return InvertMask(m), nil
+}
+
+// WildcardLen returns the prefix length matched by the wildcard mask
+// w. A wildcard mask whose one bits are not contiguous and trailing,
+// such as 0.255.0.255, has no prefix length and is rejected; use
+// NipsWildcard to count the addresses it matches.
+func WildcardLen(w netip.Addr) (int, error) {
+	if !w.IsValid() {
+		return 0, errors.New("invalid wildcard mask")
+	}
+	ones, stray := maskOnes(u128(InvertMask(w)), w.BitLen())
+	if stray >= 0 {
+		return 0, fmt.Errorf("%s is not a contiguous wildcard mask: bit %d is clear but bit %d is set", w, stray, ones)
+	}
+	return ones, nil
+}
+
+// InvertMask flips every bit of m, converting a netmask into the
+// corresponding wildcard mask and vice versa.
+func InvertMask(m netip.Addr) netip.Addr {
+	return u128(m).xor(hostMask(m.BitLen())).addr(m.Is4())
+}
+
+// NipsWildcard computes the number of IP addresses matched by the
+// wildcard mask w, which is 2 raised to the number of bits set in w.
+// Unlike a netmask, w need not be contiguous.
+func NipsWildcard(w netip.Addr) (*big.Int, error) {
+	if !w.IsValid() {
+		return nil, errors.New("invalid wildcard mask")
+	}
+	u := u128(w)
+	n := bits.OnesCount64(u.hi) + bits.OnesCount64(u.lo)
+	return new(big.Int).Lsh(big.NewInt(1), uint(n)), nil
+}
+
+// WildcardMatch reports whether addr matches base under the wildcard
+// mask w, that is, whether they agree in every bit that is clear in w.
+// All three addresses must belong to the same family; an IPv4-mapped
+// IPv6 address is not IPv4.
+func WildcardMatch(base, w, addr netip.Addr) (bool, error) {
+	switch {
+	case !base.IsValid():
+		return false, errors.New("invalid netip.Addr")
+	case !w.IsValid():
+		return false, errors.New("invalid wildcard mask")
+	case !addr.IsValid():
+		return false, errors.New("invalid netip.Addr")
+	case base.Is4() != w.Is4():
+		return false, fmt.Errorf("%s and %s are not of the same address family", base, w)
+	case base.Is4() != addr.Is4():
+		return false, fmt.Errorf("%s and %s are not of the same address family", base, addr)
+	}
+	return u128(base).xor(u128(addr)).and(u128(w).not()).isZero(), nil
+}
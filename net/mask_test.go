@@ -0,0 +1,195 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestNetmask(t *testing.T) {
+	testpairs := []struct {
+		bits     int
+		is4      bool
+		netmask  string
+		wildcard string
+	}{
+		{bits: 0, is4: true, netmask: "0.0.0.0", wildcard: "255.255.255.255"},
+		{bits: 8, is4: true, netmask: "255.0.0.0", wildcard: "0.255.255.255"},
+		{bits: 22, is4: true, netmask: "255.255.252.0", wildcard: "0.0.3.255"},
+		{bits: 24, is4: true, netmask: "255.255.255.0", wildcard: "0.0.0.255"},
+		{bits: 32, is4: true, netmask: "255.255.255.255", wildcard: "0.0.0.0"},
+		{bits: 0, netmask: "::", wildcard: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"},
+		{bits: 64, netmask: "ffff:ffff:ffff:ffff::", wildcard: "::ffff:ffff:ffff:ffff"},
+		{bits: 128, netmask: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", wildcard: "::"},
+	}
+
+	for _, pair := range testpairs {
+		netmask, err := NetmaskFromLen(pair.bits, pair.is4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if netmask.String() != pair.netmask {
+			t.Errorf("/%d: expected %s, got %v", pair.bits, pair.netmask, netmask)
+		}
+		wildcard, err := WildcardFromLen(pair.bits, pair.is4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wildcard.String() != pair.wildcard {
+			t.Errorf("/%d: expected %s, got %v", pair.bits, pair.wildcard, wildcard)
+		}
+		if InvertMask(netmask) != wildcard || InvertMask(wildcard) != netmask {
+			t.Errorf("/%d: %v and %v are not inverses", pair.bits, netmask, wildcard)
+		}
+		if n, err := NetmaskLen(netmask); err != nil || n != pair.bits {
+			t.Errorf("%v: expected %d, got %d (%v)", netmask, pair.bits, n, err)
+		}
+		if n, err := WildcardLen(wildcard); err != nil || n != pair.bits {
+			t.Errorf("%v: expected %d, got %d (%v)", wildcard, pair.bits, n, err)
+		}
+	}
+
+	for _, bits := range []int{-1, 33} {
+		if actual, err := NetmaskFromLen(bits, true); err == nil {
+			t.Errorf("/%d: expected error, got %v", bits, actual)
+		}
+	}
+	if actual, err := WildcardFromLen(129, false); err == nil {
+		t.Errorf("expected error, got %v", actual)
+	}
+}
+
+func TestNonContiguousMask(t *testing.T) {
+	testpairs := []struct {
+		mask     string
+		expected string
+	}{
+		{mask: "255.0.255.0", expected: "255.0.255.0 is not a contiguous netmask: bit 16 is set but bit 8 is not"},
+		{mask: "255.255.255.1", expected: "bit 31 is set but bit 24 is not"},
+		{mask: "0.0.0.255", expected: "bit 24 is set but bit 0 is not"},
+		{mask: "ffff::1", expected: "bit 127 is set but bit 16 is not"},
+	}
+
+	for _, pair := range testpairs {
+		actual, err := NetmaskLen(netip.MustParseAddr(pair.mask))
+		if err == nil {
+			t.Errorf("%s: expected error, got %d", pair.mask, actual)
+			continue
+		}
+		if !strings.Contains(err.Error(), pair.expected) {
+			t.Errorf("%s: expected %q, got %q", pair.mask, pair.expected, err)
+		}
+	}
+
+	for _, w := range []string{"0.255.0.255", "255.255.255.0", "0.0.0.254"} {
+		if actual, err := WildcardLen(netip.MustParseAddr(w)); err == nil {
+			t.Errorf("%s: expected error, got %d", w, actual)
+		}
+	}
+	if _, err := NetmaskLen(netip.Addr{}); err == nil {
+		t.Error("expected error for invalid netmask")
+	}
+	if _, err := WildcardLen(netip.Addr{}); err == nil {
+		t.Error("expected error for invalid wildcard mask")
+	}
+}
+
+func TestCheckMask(t *testing.T) {
+	testpairs := []struct {
+		mask  net.IPMask
+		ones  int
+		width int
+	}{
+		{mask: net.CIDRMask(24, 32), ones: 24, width: 32},
+		{mask: net.CIDRMask(120, 128), ones: 120, width: 128},
+		{mask: net.IPv4Mask(255, 255, 0, 0), ones: 16, width: 32},
+	}
+
+	for _, pair := range testpairs {
+		ones, width, err := checkMask(pair.mask)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ones != pair.ones || width != pair.width {
+			t.Errorf("%s: expected %d/%d, got %d/%d", pair.mask, pair.ones, pair.width, ones, width)
+		}
+	}
+
+	for _, m := range []net.IPMask{net.IPv4Mask(255, 0, 255, 0), {255, 255, 0}, nil} {
+		if ones, width, err := checkMask(m); err == nil {
+			t.Errorf("%s: expected error, got %d/%d", m, ones, width)
+		}
+	}
+}
+
+func TestNipsWildcard(t *testing.T) {
+	testpairs := []struct {
+		wildcard string
+		expected string
+	}{
+		{wildcard: "0.0.0.0", expected: "1"},
+		{wildcard: "0.0.0.255", expected: "256"},
+		{wildcard: "0.255.0.255", expected: "65536"},
+		{wildcard: "0.0.0.254", expected: "128"},
+		{wildcard: "255.255.255.255", expected: "4294967296"},
+		{wildcard: "::ffff:0:0:ffff", expected: "4294967296"},
+		{wildcard: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", expected: "340282366920938463463374607431768211456"},
+	}
+
+	for _, pair := range testpairs {
+		actual, err := NipsWildcard(netip.MustParseAddr(pair.wildcard))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.String() != pair.expected {
+			t.Errorf("%s: expected %s, got %s", pair.wildcard, pair.expected, actual)
+		}
+	}
+	if _, err := NipsWildcard(netip.Addr{}); err == nil {
+		t.Error("expected error for invalid wildcard mask")
+	}
+}
+
+func TestWildcardMatch(t *testing.T) {
+	testpairs := []struct {
+		base     string
+		wildcard string
+		addr     string
+		expected bool
+		err      bool
+	}{
+		{base: "10.1.0.5", wildcard: "0.255.0.0", addr: "10.77.0.5", expected: true},
+		{base: "10.1.0.5", wildcard: "0.255.0.0", addr: "10.77.0.6"},
+		{base: "192.0.2.0", wildcard: "0.0.0.254", addr: "192.0.2.42", expected: true},
+		{base: "192.0.2.0", wildcard: "0.0.0.254", addr: "192.0.2.43"},
+		{base: "2001:db8::", wildcard: "::ffff", addr: "2001:db8::1234", expected: true},
+		{base: "2001:db8::", wildcard: "::ffff", addr: "2001:db8::1:0"},
+		{base: "10.0.0.0", wildcard: "0.0.0.255", addr: "::ffff:10.0.0.1", err: true},
+		{base: "10.0.0.0", wildcard: "::ff", addr: "10.0.0.1", err: true},
+		{base: "2001:db8::", wildcard: "0.0.0.255", addr: "2001:db8::1", err: true},
+		{base: "", wildcard: "0.0.0.255", addr: "10.0.0.1", err: true},
+		{base: "10.0.0.0", wildcard: "", addr: "10.0.0.1", err: true},
+		{base: "10.0.0.0", wildcard: "0.0.0.255", addr: "", err: true},
+	}
+
+	parse := func(s string) netip.Addr {
+		if s == "" {
+			return netip.Addr{}
+		}
+		return netip.MustParseAddr(s)
+	}
+	for _, pair := range testpairs {
+		actual, err := WildcardMatch(parse(pair.base), parse(pair.wildcard), parse(pair.addr))
+		if (err != nil) != pair.err {
+			t.Errorf("%s %s %s: unexpected error %v", pair.base, pair.wildcard, pair.addr, err)
+			continue
+		}
+		if actual != pair.expected {
+			t.Errorf("%s %s %s: expected %v, got %v", pair.base, pair.wildcard, pair.addr, pair.expected, actual)
+		}
+	}
+}
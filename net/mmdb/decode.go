@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package mmdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Data section field types.
+const (
+	typeExtended  = 0
+	typePointer   = 1
+	typeString    = 2
+	typeDouble    = 3
+	typeBytes     = 4
+	typeUint16    = 5
+	typeUint32    = 6
+	typeMap       = 7
+	typeInt32     = 8
+	typeUint64    = 9
+	typeUint128   = 10
+	typeArray     = 11
+	typeContainer = 12
+	typeEnd       = 13
+	typeBool      = 14
+	typeFloat     = 15
+)
+
+// maxDepth bounds the nesting of maps, arrays and pointers.
+const maxDepth = 64
+
+// maxValues bounds the number of values one decode may visit, counting
+// map keys and pointers. Real records hold hundreds of values at most;
+// without a fixed bound, a crafted chain of pointers to pointer-laden
+// maps would take time exponential in its length.
+const maxValues = 1 << 16
+
+// intSize is the largest size of each integer type.
+var intSize = [...]int{typeUint16: 2, typeUint32: 4, typeInt32: 4, typeUint64: 8, typeUint128: 16}
+
+// errCorrupt reports malformed data.
+var errCorrupt = errors.New("mmdb: corrupt data section")
+
+// decoder decodes values from a data section.
+type decoder struct {
+	data []byte
+}
+
+// decode decodes the value at offset, returning it together with the
+// offset of the value following it. Maps decode as map[string]any,
+// arrays as []any, unsigned 128-bit integers as *big.Int, and every
+// other type as the Go type of the same name.
+func (d *decoder) decode(offset int) (any, int, error) {
+	steps := maxValues
+	return d.value(offset, 0, &steps)
+}
+
+// value decodes the value at offset for decode, charging each value
+// visited to steps.
+func (d *decoder) value(offset, depth int, steps *int) (any, int, error) {
+	if depth > maxDepth {
+		return nil, 0, fmt.Errorf("%w: nesting exceeds %d", errCorrupt, maxDepth)
+	}
+	if *steps--; *steps < 0 {
+		return nil, 0, fmt.Errorf("%w: too many values visited", errCorrupt)
+	}
+	typ, size, offset, err := d.control(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if typ == typePointer {
+		target, next, err := d.pointer(size, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		if t, _, _, err := d.control(target); err != nil || t == typePointer {
+			return nil, 0, fmt.Errorf("%w: invalid pointer target %d", errCorrupt, target)
+		}
+		v, _, err := d.value(target, depth+1, steps)
+		return v, next, err
+	}
+
+	switch typ {
+	case typeMap:
+		m := make(map[string]any, min(size, len(d.data)))
+		for range size {
+			k, next, err := d.value(offset, depth+1, steps)
+			if err != nil {
+				return nil, 0, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("%w: map key of type %T", errCorrupt, k)
+			}
+			if m[key], offset, err = d.value(next, depth+1, steps); err != nil {
+				return nil, 0, err
+			}
+		}
+		return m, offset, nil
+	case typeArray:
+		a := make([]any, 0, min(size, len(d.data)))
+		for range size {
+			v, next, err := d.value(offset, depth+1, steps)
+			if err != nil {
+				return nil, 0, err
+			}
+			a, offset = append(a, v), next
+		}
+		return a, offset, nil
+	case typeBool:
+		if size > 1 {
+			return nil, 0, fmt.Errorf("%w: boolean of size %d", errCorrupt, size)
+		}
+		return size == 1, offset, nil
+	}
+
+	if size > len(d.data)-offset {
+		return nil, 0, fmt.Errorf("%w: field overruns the data section", errCorrupt)
+	}
+	b, next := d.data[offset:offset+size], offset+size
+	switch typ {
+	case typeString:
+		return string(b), next, nil
+	case typeBytes:
+		return append([]byte(nil), b...), next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("%w: double of size %d", errCorrupt, size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("%w: float of size %d", errCorrupt, size)
+		}
+		return math.Float32frombits(binary.BigEndian.Uint32(b)), next, nil
+	case typeUint16, typeUint32, typeInt32, typeUint64, typeUint128:
+		if size > intSize[typ] {
+			return nil, 0, fmt.Errorf("%w: type %d of size %d", errCorrupt, typ, size)
+		}
+		if typ == typeUint128 {
+			return new(big.Int).SetBytes(b), next, nil
+		}
+		var u uint64
+		for _, c := range b {
+			u = u<<8 | uint64(c)
+		}
+		switch typ {
+		case typeUint16:
+			return uint16(u), next, nil
+		case typeUint32:
+			return uint32(u), next, nil
+		case typeInt32:
+			return int32(uint32(u)), next, nil
+		default:
+			return u, next, nil
+		}
+	default:
+		return nil, 0, fmt.Errorf("%w: unexpected type %d", errCorrupt, typ)
+	}
+}
+
+// control decodes the control byte at offset, along with any extended
+// type and size bytes following it. For pointers, size holds the
+// pointer size bits and value prefix unaltered.
+func (d *decoder) control(offset int) (typ, size, next int, err error) {
+	if offset < 0 || offset >= len(d.data) {
+		return 0, 0, 0, fmt.Errorf("%w: offset %d out of range", errCorrupt, offset)
+	}
+	c := d.data[offset]
+	offset++
+	typ, size = int(c>>5), int(c&0x1f)
+	if typ == typePointer {
+		return typ, size, offset, nil
+	}
+	if typ == typeExtended {
+		if offset >= len(d.data) {
+			return 0, 0, 0, fmt.Errorf("%w: truncated extended type", errCorrupt)
+		}
+		typ = int(d.data[offset]) + 7
+		offset++
+		if typ <= typeMap || typ > typeFloat {
+			return 0, 0, 0, fmt.Errorf("%w: invalid extended type %d", errCorrupt, typ)
+		}
+	}
+	if size >= 29 {
+		n := size - 28
+		if n > len(d.data)-offset {
+			return 0, 0, 0, fmt.Errorf("%w: truncated size", errCorrupt)
+		}
+		var v int
+		for _, c := range d.data[offset : offset+n] {
+			v = v<<8 | int(c)
+		}
+		size = v + []int{29, 285, 65821}[n-1]
+		offset += n
+	}
+	return typ, size, offset, nil
+}
+
+// pointer decodes a pointer whose control byte carried bits, returning
+// its target and the offset following it.
+func (d *decoder) pointer(bits, offset int) (target, next int, err error) {
+	n := bits>>3 + 1
+	if n > len(d.data)-offset {
+		return 0, 0, fmt.Errorf("%w: truncated pointer", errCorrupt)
+	}
+	v := 0
+	if n < 4 {
+		v = bits & 7
+	}
+	for _, c := range d.data[offset : offset+n] {
+		v = v<<8 | int(c)
+	}
+	return v + []int{0, 2048, 526336, 0}[n-1], offset + n, nil
+}
@@ -0,0 +1,211 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package mmdb
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// vectors pairs values with their encodings, as given by the format
+// specification.
+var vectors = []struct {
+	value    any
+	encoding string
+}{
+	{value: "", encoding: "\x40"},
+	{value: "Foo", encoding: "\x43Foo"},
+	{value: strings.Repeat("x", 29), encoding: "\x5d\x00" + strings.Repeat("x", 29)},
+	{value: strings.Repeat("x", 300), encoding: "\x5e\x00\x0f" + strings.Repeat("x", 300)},
+	{value: strings.Repeat("x", 70000), encoding: "\x5f\x00\x10\x53" + strings.Repeat("x", 70000)},
+	{value: []byte{1, 2}, encoding: "\x82\x01\x02"},
+	{value: 42.5, encoding: "\x68\x40\x45\x40\x00\x00\x00\x00\x00"},
+	{value: float32(1.5), encoding: "\x04\x08\x3f\xc0\x00\x00"},
+	{value: uint16(0), encoding: "\xa0"},
+	{value: uint16(255), encoding: "\xa1\xff"},
+	{value: uint16(65535), encoding: "\xa2\xff\xff"},
+	{value: uint32(1 << 24), encoding: "\xc4\x01\x00\x00\x00"},
+	{value: int32(-1), encoding: "\x04\x01\xff\xff\xff\xff"},
+	{value: int32(500), encoding: "\x02\x01\x01\xf4"},
+	{value: uint64(1 << 40), encoding: "\x06\x02\x01\x00\x00\x00\x00\x00"},
+	{value: new(big.Int).Lsh(big.NewInt(1), 127), encoding: "\x10\x03\x80" + strings.Repeat("\x00", 15)},
+	{value: true, encoding: "\x01\x07"},
+	{value: false, encoding: "\x00\x07"},
+	{value: map[string]any{}, encoding: "\xe0"},
+	{value: map[string]any{"en": "Foo", "de": "Bar"}, encoding: "\xe2\x42de\x43Bar\x42en\x43Foo"},
+	{value: []any{"Foo", uint16(1)}, encoding: "\x02\x04\x43Foo\xa1\x01"},
+	{value: map[string]any{"a": []any{map[string]any{"b": true}}}, encoding: "\xe1\x41a\x01\x04\xe1\x41b\x01\x07"},
+}
+
+func TestDecode(t *testing.T) {
+	for _, pair := range vectors {
+		d := &decoder{[]byte(pair.encoding)}
+		actual, next, err := d.decode(0)
+		if err != nil {
+			t.Fatalf("%q: %v", pair.encoding, err)
+		}
+		if !reflect.DeepEqual(actual, pair.value) {
+			if a, ok := actual.(*big.Int); !ok || a.Cmp(pair.value.(*big.Int)) != 0 {
+				t.Errorf("%q: expected %#v, got %#v", pair.encoding, pair.value, actual)
+			}
+		}
+		if next != len(pair.encoding) {
+			t.Errorf("%q: expected next offset %d, got %d", pair.encoding, len(pair.encoding), next)
+		}
+	}
+}
+
+func TestDecodePointer(t *testing.T) {
+	testpairs := []struct {
+		pointer string
+		target  int
+	}{
+		{pointer: "\x20\x05", target: 5},
+		{pointer: "\x27\xff", target: 2047},
+		{pointer: "\x28\x00\x00", target: 2048},
+		{pointer: "\x2f\xff\xff", target: 526335},
+		{pointer: "\x30\x00\x00\x00", target: 526336},
+		{pointer: "\x38\x00\x00\x00\x10", target: 16},
+	}
+
+	for _, pair := range testpairs {
+		d := &decoder{[]byte(pair.pointer)}
+		typ, bits, offset, err := d.control(0)
+		if err != nil || typ != typePointer {
+			t.Fatalf("%q: expected a pointer, got type %d (%v)", pair.pointer, typ, err)
+		}
+		target, next, err := d.pointer(bits, offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target != pair.target || next != len(pair.pointer) {
+			t.Errorf("%q: expected %d and %d, got %d and %d", pair.pointer, pair.target, len(pair.pointer), target, next)
+		}
+	}
+
+	// Decoding resumes after the pointer, not after its target.
+	d := &decoder{[]byte("\x43Foo\xe2\x41a\x20\x00\x41b\x20\x00")}
+	actual, next, err := d.decode(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, map[string]any{"a": "Foo", "b": "Foo"}) || next != len(d.data) {
+		t.Errorf("unexpected %v at %d", actual, next)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"\x43Fo",
+		"\x20\x00",             // a pointer to itself
+		"\x5d",                 // a truncated size
+		"\x00",                 // a truncated extended type
+		"\x00\x00",             // an extended map
+		"\x00\x09",             // an extended type beyond float
+		"\x00\x05",             // a data cache container
+		"\x00\x06",             // an end marker
+		"\x02\x07",             // a boolean of size 2
+		"\x67\x00",             // a double of size 7
+		"\xa3\x00\x00\x00",     // a uint16 of size 3
+		"\xe1\xa1\x01\xa1\x01", // a map with an integer key
+		"\xe2\x41a\x41b",       // a truncated map
+		"\x02\x04\x41a",        // a truncated array
+		"\x01\x04" + strings.Repeat("\x01\x04", maxDepth+1),
+	} {
+		if v, _, err := (&decoder{[]byte(s)}).decode(0); err == nil {
+			t.Errorf("%q: expected error, got %v", s, v)
+		}
+	}
+
+	// No truncation of a valid encoding panics.
+	var all bytes.Buffer
+	for _, pair := range vectors {
+		all.WriteString(pair.encoding)
+	}
+	b := all.Bytes()
+	for n := range len(b) {
+		d := &decoder{b[:n]}
+		for offset := 0; offset < n; {
+			_, next, err := d.decode(offset)
+			if err != nil {
+				break
+			}
+			offset = next
+		}
+	}
+}
+
+// pointerDAG returns 25 three-entry maps, 325 bytes in all, whose
+// values all point to the next map, the last map's values pointing at
+// a key. Decoding it naively visits some 3^25 values.
+func pointerDAG() []byte {
+	const levels, levelLen = 25, 13
+	var b []byte
+	for i := range levels {
+		target := (i + 1) * levelLen
+		if i == levels-1 {
+			target = 1
+		}
+		b = append(b, 0xe3)
+		for _, k := range "abc" {
+			b = append(b, 0x41, byte(k), 0x20|byte(target>>8), byte(target))
+		}
+	}
+	return b
+}
+
+func TestDecodePointerDAG(t *testing.T) {
+	b := pointerDAG()
+	if len(b) != 325 {
+		t.Fatalf("expected 325 bytes, got %d", len(b))
+	}
+	start := time.Now()
+	if _, _, err := (&decoder{b}).decode(0); !errors.Is(err, errCorrupt) {
+		t.Errorf("expected errCorrupt, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("decoding took %v", elapsed)
+	}
+
+	// Used as metadata, it must not hang FromBytes.
+	if _, err := FromBytes(append(bytes.Clone(metadataMarker), b...)); !errors.Is(err, errCorrupt) {
+		t.Errorf("expected errCorrupt, got %v", err)
+	}
+
+	// Nor may a large data section buy it more time: both records of a
+	// one-node tree lead to it, followed by 4 MiB of padding.
+	buf := []byte{0, 0, 17, 0, 0, 17}
+	buf = append(buf, make([]byte, dataSectionSeparator)...)
+	buf = append(buf, b...)
+	buf = append(buf, make([]byte, 4<<20)...)
+	buf, err := encode(append(buf, metadataMarker...), map[string]any{
+		"node_count":                  uint32(1),
+		"record_size":                 uint16(24),
+		"ip_version":                  uint16(4),
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(0),
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := FromBytes(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start = time.Now()
+	if _, err := r.Lookup(netip.MustParseAddr("192.0.2.1")); !errors.Is(err, errCorrupt) {
+		t.Errorf("expected errCorrupt, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("lookup took %v", elapsed)
+	}
+}
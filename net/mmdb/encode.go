@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package mmdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+)
+
+// maxFieldSize is the largest size a control byte can express.
+const maxFieldSize = 65821 + 1<<24 - 1
+
+// encode appends the data section encoding of v to b. It accepts the
+// types decode produces, along with map[string]string and []string.
+// Map keys are written in sorted order, so equal values encode
+// identically.
+func encode(b []byte, v any, depth int) ([]byte, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("mmdb: nesting exceeds %d", maxDepth)
+	}
+	switch v := v.(type) {
+	case string:
+		return appendField(b, typeString, []byte(v))
+	case []byte:
+		return appendField(b, typeBytes, v)
+	case float64:
+		return appendField(b, typeDouble, binary.BigEndian.AppendUint64(nil, math.Float64bits(v)))
+	case float32:
+		return appendField(b, typeFloat, binary.BigEndian.AppendUint32(nil, math.Float32bits(v)))
+	case bool:
+		size := 0
+		if v {
+			size = 1
+		}
+		return appendControl(b, typeBool, size)
+	case uint16:
+		return appendField(b, typeUint16, minimal(uint64(v)))
+	case uint32:
+		return appendField(b, typeUint32, minimal(uint64(v)))
+	case uint64:
+		return appendField(b, typeUint64, minimal(v))
+	case int32:
+		if v < 0 {
+			return appendField(b, typeInt32, binary.BigEndian.AppendUint32(nil, uint32(v)))
+		}
+		return appendField(b, typeInt32, minimal(uint64(v)))
+	case *big.Int:
+		if v.Sign() < 0 || v.BitLen() > 128 {
+			return nil, fmt.Errorf("mmdb: %s does not fit in a uint128", v)
+		}
+		return appendField(b, typeUint128, v.Bytes())
+	case map[string]any:
+		return encodeMap(b, v, depth)
+	case map[string]string:
+		m := make(map[string]any, len(v))
+		for k, s := range v {
+			m[k] = s
+		}
+		return encodeMap(b, m, depth)
+	case []any:
+		return encodeArray(b, v, depth)
+	case []string:
+		a := make([]any, len(v))
+		for i, s := range v {
+			a[i] = s
+		}
+		return encodeArray(b, a, depth)
+	default:
+		return nil, fmt.Errorf("mmdb: unsupported type %T", v)
+	}
+}
+
+func encodeMap(b []byte, m map[string]any, depth int) ([]byte, error) {
+	b, err := appendControl(b, typeMap, len(m))
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		if b, err = appendField(b, typeString, []byte(k)); err != nil {
+			return nil, err
+		}
+		if b, err = encode(b, m[k], depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func encodeArray(b []byte, a []any, depth int) ([]byte, error) {
+	b, err := appendControl(b, typeArray, len(a))
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range a {
+		if b, err = encode(b, v, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// countValues returns the number of values decode visits for the
+// encoding of v, counting map keys.
+func countValues(v any) int {
+	switch v := v.(type) {
+	case map[string]any:
+		n := 1
+		for _, e := range v {
+			n += 1 + countValues(e)
+		}
+		return n
+	case map[string]string:
+		return 1 + 2*len(v)
+	case []any:
+		n := 1
+		for _, e := range v {
+			n += countValues(e)
+		}
+		return n
+	case []string:
+		return 1 + len(v)
+	default:
+		return 1
+	}
+}
+
+// minimal returns u big-endian without leading zero bytes.
+func minimal(u uint64) []byte {
+	b := binary.BigEndian.AppendUint64(nil, u)
+	for len(b) > 0 && b[0] == 0 {
+		b = b[1:]
+	}
+	return b
+}
+
+// appendField appends a control byte for typ followed by payload.
+func appendField(b []byte, typ int, payload []byte) ([]byte, error) {
+	b, err := appendControl(b, typ, len(payload))
+	if err != nil {
+		return nil, err
+	}
+	return append(b, payload...), nil
+}
+
+// appendControl appends the control byte, and any extended type and
+// size bytes, for a field of type typ and size size.
+func appendControl(b []byte, typ, size int) ([]byte, error) {
+	if size > maxFieldSize {
+		return nil, fmt.Errorf("mmdb: field size %d exceeds %d", size, maxFieldSize)
+	}
+	var ctrl byte
+	var ext []byte
+	if typ > typeMap {
+		ext = append(ext, byte(typ-7))
+	} else {
+		ctrl = byte(typ) << 5
+	}
+	switch {
+	case size < 29:
+		ctrl |= byte(size)
+	case size < 285:
+		ctrl |= 29
+		ext = append(ext, byte(size-29))
+	case size < 65821:
+		ctrl |= 30
+		ext = binary.BigEndian.AppendUint16(ext, uint16(size-285))
+	default:
+		ctrl |= 31
+		n := size - 65821
+		ext = append(ext, byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(append(b, ctrl), ext...), nil
+}
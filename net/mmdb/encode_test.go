@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package mmdb
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	for _, pair := range vectors {
+		actual, err := encode(nil, pair.value, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(actual) != pair.encoding {
+			t.Errorf("%#v: expected %q, got %q", pair.value, pair.encoding, actual)
+		}
+	}
+}
+
+func TestEncodeConvenienceTypes(t *testing.T) {
+	testpairs := []struct {
+		value    any
+		expected any
+	}{
+		{value: map[string]string{"en": "Foo"}, expected: map[string]any{"en": "Foo"}},
+		{value: []string{"de", "en"}, expected: []any{"de", "en"}},
+	}
+
+	for _, pair := range testpairs {
+		b, err := encode(nil, pair.value, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, _, err := (&decoder{b}).decode(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(actual, pair.expected) {
+			t.Errorf("expected %#v, got %#v", pair.expected, actual)
+		}
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	deep := any("leaf")
+	for range maxDepth + 1 {
+		deep = []any{deep}
+	}
+	for _, v := range []any{
+		42,
+		nil,
+		map[string]int{"a": 1},
+		big.NewInt(-1),
+		new(big.Int).Lsh(big.NewInt(1), 128),
+		[]any{struct{}{}},
+		deep,
+	} {
+		if _, err := encode(nil, v, 0); err == nil {
+			t.Errorf("%#v: expected error", v)
+		}
+	}
+}
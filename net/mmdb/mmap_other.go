@@ -0,0 +1,26 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+//go:build !unix
+
+package mmdb
+
+import (
+	"io"
+	"os"
+)
+
+// mmap reads the first size bytes of f, as memory mapping is not
+// supported on this platform.
+func mmap(f *os.File, size int) ([]byte, error) {
+	b := make([]byte, size)
+	if _, err := io.ReadFull(f, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// munmap releases b, as returned by mmap.
+func munmap(b []byte) error {
+	return nil
+}
@@ -0,0 +1,21 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+//go:build unix
+
+package mmdb
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmap maps the first size bytes of f read-only.
+func mmap(f *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+// munmap unmaps b, as returned by mmap.
+func munmap(b []byte) error {
+	return syscall.Munmap(b)
+}
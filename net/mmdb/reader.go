@@ -0,0 +1,287 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+// Package mmdb reads and writes MaxMind DB files, the format of GeoIP2
+// and similar IP metadata databases, as specified at
+// https://maxmind.github.io/MaxMind-DB/.
+package mmdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/netip"
+	"os"
+	"sync"
+	"time"
+
+	ynet "github.com/yesmar/y/net"
+)
+
+// metadataMarker precedes the metadata at the end of a database.
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// maxMetadataLen bounds the search for the metadata marker.
+const maxMetadataLen = 128 << 10
+
+// dataSectionSeparator is the number of zero bytes between the search
+// tree and the data section.
+const dataSectionSeparator = 16
+
+// Metadata describes a database.
+type Metadata struct {
+	NodeCount    uint32            // the number of nodes in the search tree
+	RecordSize   int               // the size of a search tree record in bits
+	IPVersion    int               // 4 or 6
+	DatabaseType string            // the kind of data the database holds
+	Languages    []string          // the locales the database covers
+	Description  map[string]string // descriptions, keyed by locale
+	MajorVersion int               // the major version of the format
+	MinorVersion int               // the minor version of the format
+	BuildTime    time.Time         // when the database was built
+}
+
+// Reader looks up addresses in a database. It is safe for concurrent
+// use; Close waits for lookups in progress to finish.
+type Reader struct {
+	Metadata Metadata
+
+	mu        sync.RWMutex // guards the fields below against Close
+	buf       []byte       // the whole database
+	tree      []byte       // the search tree
+	data      decoder      // the data section
+	release   func() error // unmaps buf, if mapped
+	ipv4Start uint32       // the node for ::/96 in an IPv6 tree
+	ipv4Depth int          // the depth of ipv4Start
+}
+
+// Open memory-maps the database at path. The Reader must be closed
+// when no longer needed.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() == 0 || int64(int(fi.Size())) != fi.Size() {
+		return nil, fmt.Errorf("mmdb: %s: invalid size %d", path, fi.Size())
+	}
+	buf, err := mmap(f, int(fi.Size()))
+	if err != nil {
+		return nil, err
+	}
+	r, err := FromBytes(buf)
+	if err != nil {
+		munmap(buf)
+		return nil, err
+	}
+	r.release = func() error { return munmap(buf) }
+	return r, nil
+}
+
+// FromBytes returns a Reader for the database in buf, which must not
+// be modified while the Reader is in use.
+func FromBytes(buf []byte) (*Reader, error) {
+	start := max(len(buf)-maxMetadataLen, 0)
+	i := bytes.LastIndex(buf[start:], metadataMarker)
+	if i < 0 {
+		return nil, errors.New("mmdb: metadata not found")
+	}
+	i += start
+	md, err := decodeMetadata(buf[i+len(metadataMarker):])
+	if err != nil {
+		return nil, err
+	}
+	if int64(md.NodeCount)*int64(md.RecordSize)/4+dataSectionSeparator > int64(i) {
+		return nil, errors.New("mmdb: search tree overruns the file")
+	}
+	treeLen := int(md.NodeCount) * md.RecordSize / 4
+	r := &Reader{
+		Metadata: md,
+		buf:      buf,
+		tree:     buf[:treeLen],
+		data:     decoder{buf[treeLen+dataSectionSeparator : i]},
+	}
+	if md.IPVersion == 6 {
+		node, depth := uint32(0), 0
+		for ; depth < 96 && node < md.NodeCount; depth++ {
+			node = r.record(node, 0)
+		}
+		r.ipv4Start, r.ipv4Depth = node, depth
+	}
+	return r, nil
+}
+
+// decodeMetadata decodes and checks the metadata map in b.
+func decodeMetadata(b []byte) (Metadata, error) {
+	v, _, err := (&decoder{b}).decode(0)
+	if err != nil {
+		return Metadata{}, err
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return Metadata{}, errors.New("mmdb: metadata is not a map")
+	}
+	unsigned := func(key string) (uint64, error) {
+		switch n := m[key].(type) {
+		case uint16:
+			return uint64(n), nil
+		case uint32:
+			return uint64(n), nil
+		case uint64:
+			return n, nil
+		case nil:
+			return 0, fmt.Errorf("mmdb: metadata lacks %s", key)
+		default:
+			return 0, fmt.Errorf("mmdb: metadata %s has type %T", key, n)
+		}
+	}
+	var md Metadata
+	for _, f := range []struct {
+		key   string
+		limit uint64
+		set   func(uint64)
+	}{
+		{"node_count", 1<<32 - 1, func(n uint64) { md.NodeCount = uint32(n) }},
+		{"record_size", 32, func(n uint64) { md.RecordSize = int(n) }},
+		{"ip_version", 6, func(n uint64) { md.IPVersion = int(n) }},
+		{"binary_format_major_version", 1<<16 - 1, func(n uint64) { md.MajorVersion = int(n) }},
+		{"binary_format_minor_version", 1<<16 - 1, func(n uint64) { md.MinorVersion = int(n) }},
+		{"build_epoch", 1<<63 - 1, func(n uint64) { md.BuildTime = time.Unix(int64(n), 0).UTC() }},
+	} {
+		n, err := unsigned(f.key)
+		if err != nil {
+			return Metadata{}, err
+		}
+		if n > f.limit {
+			return Metadata{}, fmt.Errorf("mmdb: metadata %s is %d", f.key, n)
+		}
+		f.set(n)
+	}
+	if md.MajorVersion != 2 {
+		return Metadata{}, fmt.Errorf("mmdb: unsupported format version %d", md.MajorVersion)
+	}
+	if md.RecordSize != 24 && md.RecordSize != 28 && md.RecordSize != 32 {
+		return Metadata{}, fmt.Errorf("mmdb: unsupported record size %d", md.RecordSize)
+	}
+	if md.IPVersion != 4 && md.IPVersion != 6 {
+		return Metadata{}, fmt.Errorf("mmdb: unsupported IP version %d", md.IPVersion)
+	}
+	md.DatabaseType, _ = m["database_type"].(string)
+	if langs, ok := m["languages"].([]any); ok {
+		for _, l := range langs {
+			if s, ok := l.(string); ok {
+				md.Languages = append(md.Languages, s)
+			}
+		}
+	}
+	if desc, ok := m["description"].(map[string]any); ok {
+		md.Description = make(map[string]string, len(desc))
+		for k, v := range desc {
+			if s, ok := v.(string); ok {
+				md.Description[k] = s
+			}
+		}
+	}
+	return md, nil
+}
+
+// Close releases the memory mapping of a Reader returned by Open.
+func (r *Reader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.release == nil {
+		return nil
+	}
+	err := r.release()
+	r.release, r.buf, r.tree, r.data = nil, nil, nil, decoder{}
+	return err
+}
+
+// record returns the left (bit 0) or right (bit 1) record of node.
+func (r *Reader) record(node uint32, bit int) uint32 {
+	b := r.tree[int(node)*r.Metadata.RecordSize/4:]
+	switch r.Metadata.RecordSize {
+	case 24:
+		b = b[bit*3:]
+		return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+	case 28:
+		if bit == 0 {
+			return uint32(b[3]&0xf0)<<20 | uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+		}
+		return uint32(b[3]&0x0f)<<24 | uint32(b[4])<<16 | uint32(b[5])<<8 | uint32(b[6])
+	default:
+		b = b[bit*4:]
+		return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+	}
+}
+
+// Result is the outcome of a lookup.
+type Result struct {
+	// Network is the prefix sharing the record of the address looked
+	// up, or, if there is no record, the prefix known to have none.
+	Network netip.Prefix
+	// Record is the decoded record, or nil if Found is false.
+	Record any
+	// Found reports whether the database holds a record for the
+	// address.
+	Found bool
+}
+
+// Size returns the number of addresses in the matched network.
+func (res Result) Size() *big.Int {
+	n, err := ynet.NipsPrefix(res.Network)
+	if err != nil {
+		return new(big.Int)
+	}
+	return n
+}
+
+// Lookup returns the record for addr, along with the network it
+// belongs to. Records decode as described for the data section types:
+// maps as map[string]any, arrays as []any, uint128 as *big.Int, and
+// the other types as the Go types of the same names. IPv4 addresses
+// are looked up under ::/96 in an IPv6 database, and IPv4-mapped IPv6
+// addresses are looked up as they are.
+func (r *Reader) Lookup(addr netip.Addr) (Result, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.buf == nil {
+		return Result{}, errors.New("mmdb: lookup on closed Reader")
+	}
+	if !addr.IsValid() {
+		return Result{}, errors.New("invalid netip.Addr")
+	}
+	addr = addr.WithZone("")
+	if addr.Is6() && r.Metadata.IPVersion == 4 {
+		return Result{}, fmt.Errorf("mmdb: cannot look up IPv6 address %s in an IPv4 database", addr)
+	}
+
+	node, depth, offset := uint32(0), 0, 0
+	if addr.Is4() && r.Metadata.IPVersion == 6 {
+		node, depth, offset = r.ipv4Start, r.ipv4Depth, 96
+	}
+	b := addr.AsSlice()
+	for i := depth - offset; i < len(b)*8 && node < r.Metadata.NodeCount; i++ {
+		node = r.record(node, int(b[i/8]>>(7-i%8)&1))
+		depth++
+	}
+	if node < r.Metadata.NodeCount {
+		return Result{}, errors.New("mmdb: search tree deeper than the address")
+	}
+	res := Result{Network: netip.PrefixFrom(addr, max(depth-offset, 0)).Masked()}
+	if node == r.Metadata.NodeCount {
+		return res, nil
+	}
+	v, _, err := r.data.decode(int(node-r.Metadata.NodeCount) - dataSectionSeparator)
+	if err != nil {
+		return Result{}, err
+	}
+	res.Record, res.Found = v, true
+	return res, nil
+}
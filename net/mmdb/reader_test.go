@@ -0,0 +1,282 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package mmdb
+
+import (
+	"bytes"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOpen(t *testing.T) {
+	w, _ := NewWriter(6)
+	if err := w.InsertMap(sampleRecords()); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "sample.mmdb")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteTo(f); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := r.Lookup(netip.MustParseAddr("2001:db8:ffff::1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := res.Record.(map[string]any)["names"].(map[string]any)
+	if names["de"] != "Deutschland" {
+		t.Errorf("expected Deutschland, got %v", names["de"])
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Lookup(netip.MustParseAddr("2001:db8::1")); err == nil {
+		t.Error("expected error after Close")
+	}
+
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.mmdb")); err == nil {
+		t.Error("expected error")
+	}
+	empty := filepath.Join(t.TempDir(), "empty.mmdb")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(empty); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestCloseDuringLookups(t *testing.T) {
+	// Run with -race: Close must wait for lookups in progress rather
+	// than unmap the database beneath them.
+	r, err := Open("testdata/test-ipv4-24.mmdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := netip.MustParseAddr("1.1.1.1")
+	var wg sync.WaitGroup
+	started := make(chan struct{})
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; ; n++ {
+				if n == 10 && i == 0 {
+					close(started)
+				}
+				res, err := r.Lookup(addr)
+				if err != nil {
+					return
+				}
+				if !res.Found {
+					t.Errorf("%s: expected a record", addr)
+					return
+				}
+			}
+		}()
+	}
+	<-started
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	if _, err := r.Lookup(addr); err == nil {
+		t.Error("expected error after Close")
+	}
+}
+
+func TestLookupMisses(t *testing.T) {
+	w, _ := NewWriter(6)
+	r := build(t, w, sampleRecords())
+	testpairs := []struct {
+		addr    string
+		network string
+	}{
+		{addr: "192.0.2.0", network: "192.0.2.0/25"},
+		{addr: "203.0.113.1", network: "200.0.0.0/5"},
+		{addr: "198.51.100.6", network: "198.51.100.6/32"},
+		{addr: "2001:db9::1", network: "2001:db9::/32"},
+		{addr: "::ffff:192.0.2.1", network: "::8000:0:0/81"},
+		{addr: "fe80::1%eth0", network: "8000::/1"},
+	}
+
+	for _, pair := range testpairs {
+		res, err := r.Lookup(netip.MustParseAddr(pair.addr))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Found != (pair.addr == "192.0.2.0") {
+			t.Errorf("%s: unexpected Found %v", pair.addr, res.Found)
+		}
+		if res.Network != netip.MustParsePrefix(pair.network) {
+			t.Errorf("%s: expected %s, got %s", pair.addr, pair.network, res.Network)
+		}
+	}
+}
+
+func TestResultSize(t *testing.T) {
+	w, _ := NewWriter(6)
+	r := build(t, w, sampleRecords())
+	testpairs := []struct {
+		addr     string
+		expected string
+	}{
+		{addr: "10.0.0.1", expected: "16777216"},
+		{addr: "198.51.100.7", expected: "1"},
+		{addr: "2001:db8:1::1", expected: "1208925819614629174706176"},
+	}
+
+	for _, pair := range testpairs {
+		res, err := r.Lookup(netip.MustParseAddr(pair.addr))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := res.Size().String(); actual != pair.expected {
+			t.Errorf("%s: expected %s, got %s", pair.addr, pair.expected, actual)
+		}
+	}
+	if actual := (Result{}).Size().String(); actual != "0" {
+		t.Errorf("expected 0, got %s", actual)
+	}
+}
+
+func TestIPv4Database(t *testing.T) {
+	w, _ := NewWriter(4)
+	r := build(t, w, map[netip.Prefix]any{netip.MustParsePrefix("192.0.2.0/24"): uint16(1)})
+	res, err := r.Lookup(netip.MustParseAddr("192.0.2.9"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Found || res.Record != uint16(1) || res.Network != netip.MustParsePrefix("192.0.2.0/24") {
+		t.Errorf("unexpected result %+v", res)
+	}
+	if _, err := r.Lookup(netip.MustParseAddr("2001:db8::1")); err == nil {
+		t.Error("expected error")
+	}
+	if _, err := r.Lookup(netip.Addr{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+// TestFixtures reads databases laid out independently of Writer; see
+// testdata/gen.go.
+func TestFixtures(t *testing.T) {
+	testpairs := []struct {
+		addr    string
+		network string
+		ip      string // the record's ip, or empty if there is none
+	}{
+		{addr: "1.1.1.1", network: "1.1.1.1/32", ip: "1.1.1.1"},
+		{addr: "1.1.1.3", network: "1.1.1.2/31", ip: "1.1.1.2"},
+		{addr: "1.1.1.7", network: "1.1.1.4/30", ip: "1.1.1.4"},
+		{addr: "1.1.1.15", network: "1.1.1.8/29", ip: "1.1.1.8"},
+		{addr: "1.1.1.17", network: "1.1.1.16/28", ip: "1.1.1.16"},
+		{addr: "1.1.1.32", network: "1.1.1.32/32", ip: "1.1.1.32"},
+		{addr: "1.1.1.0", network: "1.1.1.0/32"},
+		{addr: "1.1.1.33", network: "1.1.1.33/32"},
+		{addr: "1.1.1.40", network: "1.1.1.40/29"},
+		{addr: "2.0.0.0", network: "2.0.0.0/7"},
+	}
+
+	for _, size := range []int{24, 28, 32} {
+		r, err := Open(fmt.Sprintf("testdata/test-ipv4-%d.mmdb", size))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { r.Close() })
+
+		md := r.Metadata
+		if md.RecordSize != size || md.IPVersion != 4 || md.NodeCount != 37 ||
+			md.DatabaseType != "Test" || md.MajorVersion != 2 || md.MinorVersion != 0 ||
+			!md.BuildTime.Equal(time.Unix(1700000000, 0)) {
+			t.Errorf("%d: unexpected metadata %+v", size, md)
+		}
+		if !slices.Equal(md.Languages, []string{"en", "zh"}) || md.Description["zh"] != "Test Database Chinese" {
+			t.Errorf("%d: unexpected metadata %+v", size, md)
+		}
+
+		for _, pair := range testpairs {
+			res, err := r.Lookup(netip.MustParseAddr(pair.addr))
+			if err != nil {
+				t.Fatalf("%d: %s: %v", size, pair.addr, err)
+			}
+			if res.Network != netip.MustParsePrefix(pair.network) {
+				t.Errorf("%d: %s: expected %s, got %s", size, pair.addr, pair.network, res.Network)
+			}
+			if res.Found != (pair.ip != "") {
+				t.Errorf("%d: %s: unexpected Found %v", size, pair.addr, res.Found)
+				continue
+			}
+			if res.Found && !reflect.DeepEqual(res.Record, map[string]any{"ip": pair.ip}) {
+				t.Errorf("%d: %s: expected ip %s, got %v", size, pair.addr, pair.ip, res.Record)
+			}
+		}
+	}
+}
+
+func TestFromBytesErrors(t *testing.T) {
+	w, _ := NewWriter(4)
+	if err := w.Insert(netip.MustParsePrefix("192.0.2.0/24"), "x"); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	good := buf.Bytes()
+	marker := bytes.LastIndex(good, metadataMarker)
+
+	for name, b := range map[string][]byte{
+		"empty":          nil,
+		"no marker":      good[:marker],
+		"no metadata":    good[:marker+len(metadataMarker)],
+		"not a map":      append(bytes.Clone(good[:marker+len(metadataMarker)]), "\x43Foo"...),
+		"tree overflows": good[len(good)-marker-len(metadataMarker)-1:],
+	} {
+		if _, err := FromBytes(b); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	for key, value := range map[string]any{
+		"record_size":                 uint16(20),
+		"ip_version":                  uint16(5),
+		"binary_format_major_version": uint16(1),
+		"node_count":                  "one",
+	} {
+		md := map[string]any{
+			"node_count":                  uint32(1),
+			"record_size":                 uint16(24),
+			"ip_version":                  uint16(4),
+			"binary_format_major_version": uint16(2),
+			"binary_format_minor_version": uint16(0),
+			"build_epoch":                 uint64(0),
+		}
+		md[key] = value
+		enc, err := encode(append(make([]byte, 6+dataSectionSeparator), metadataMarker...), md, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := FromBytes(enc); err == nil {
+			t.Errorf("%s %v: expected error", key, value)
+		}
+	}
+}
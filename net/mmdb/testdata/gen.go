@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+//go:build ignore
+
+// gen writes the database fixtures used by the tests. Run it from this
+// directory with "go run gen.go".
+//
+// The databases hold the networks and records of MaxMind's
+// MaxMind-DB-test-ipv4-{24,28,32}.mmdb test data, but are laid out
+// here from the specification alone, independently of the package's
+// writer and encoder, and in the ways it does not: search tree nodes
+// are numbered depth first, map keys after the first are pointers to
+// it, and the metadata keys are neither sorted nor minimally sized.
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net/netip"
+	"os"
+)
+
+var networks = []string{
+	"1.1.1.1/32",
+	"1.1.1.2/31",
+	"1.1.1.4/30",
+	"1.1.1.8/29",
+	"1.1.1.16/28",
+	"1.1.1.32/32",
+}
+
+// ctrl returns the control bytes of a field of type typ and size size.
+func ctrl(typ, size int) []byte {
+	if size >= 29 {
+		log.Fatalf("size %d unsupported", size)
+	}
+	if typ > 7 {
+		return []byte{byte(size), byte(typ - 7)}
+	}
+	return []byte{byte(typ<<5 | size)}
+}
+
+func str(s string) []byte { return append(ctrl(2, len(s)), s...) }
+
+// ptr returns a pointer to offset, which must be less than 2048.
+func ptr(offset int) []byte { return []byte{0x20 | byte(offset>>8), byte(offset)} }
+
+type node struct {
+	child [2]*node
+	data  [2]int // 1 + the offset of the record, or 0
+	id    int
+}
+
+func main() {
+	// Every record is the map {"ip": network address}.
+	var data []byte
+	keyOffset := -1
+	offsets := make([]int, len(networks))
+	for i, s := range networks {
+		offsets[i] = len(data)
+		data = append(data, ctrl(7, 1)...)
+		if keyOffset < 0 {
+			keyOffset = len(data)
+			data = append(data, str("ip")...)
+		} else {
+			data = append(data, ptr(keyOffset)...)
+		}
+		data = append(data, str(netip.MustParsePrefix(s).Addr().String())...)
+	}
+
+	root := &node{}
+	for i, s := range networks {
+		p := netip.MustParsePrefix(s)
+		a := p.Addr().As4()
+		n := root
+		for j := range p.Bits() - 1 {
+			b := a[j/8] >> (7 - j%8) & 1
+			if n.child[b] == nil {
+				n.child[b] = &node{}
+			}
+			n = n.child[b]
+		}
+		j := p.Bits() - 1
+		n.data[a[j/8]>>(7-j%8)&1] = offsets[i] + 1
+	}
+	var nodes []*node
+	var number func(n *node)
+	number = func(n *node) {
+		n.id = len(nodes)
+		nodes = append(nodes, n)
+		for _, c := range n.child {
+			if c != nil {
+				number(c)
+			}
+		}
+	}
+	number(root)
+
+	for _, size := range []int{24, 28, 32} {
+		var out []byte
+		for _, n := range nodes {
+			var rec [2]uint32
+			for i := range rec {
+				switch {
+				case n.child[i] != nil:
+					rec[i] = uint32(n.child[i].id)
+				case n.data[i] > 0:
+					rec[i] = uint32(len(nodes) + 16 + n.data[i] - 1)
+				default:
+					rec[i] = uint32(len(nodes))
+				}
+			}
+			switch size {
+			case 24:
+				out = append(out, byte(rec[0]>>16), byte(rec[0]>>8), byte(rec[0]),
+					byte(rec[1]>>16), byte(rec[1]>>8), byte(rec[1]))
+			case 28:
+				out = append(out, byte(rec[0]>>16), byte(rec[0]>>8), byte(rec[0]),
+					byte(rec[0]>>24&0xf)<<4|byte(rec[1]>>24&0xf),
+					byte(rec[1]>>16), byte(rec[1]>>8), byte(rec[1]))
+			case 32:
+				out = binary.BigEndian.AppendUint32(out, rec[0])
+				out = binary.BigEndian.AppendUint32(out, rec[1])
+			}
+		}
+		out = append(out, make([]byte, 16)...)
+		out = append(out, data...)
+		out = append(out, "\xab\xcd\xefMaxMind.com"...)
+
+		out = append(out, ctrl(7, 9)...)
+		out = append(out, str("node_count")...)
+		out = append(append(out, ctrl(6, 4)...), binary.BigEndian.AppendUint32(nil, uint32(len(nodes)))...)
+		out = append(out, str("record_size")...)
+		out = append(append(out, ctrl(5, 2)...), 0, byte(size))
+		out = append(out, str("ip_version")...)
+		out = append(append(out, ctrl(5, 1)...), 4)
+		out = append(out, str("database_type")...)
+		out = append(out, str("Test")...)
+		out = append(out, str("languages")...)
+		out = append(out, ctrl(11, 2)...)
+		out = append(append(out, str("en")...), str("zh")...)
+		out = append(out, str("binary_format_major_version")...)
+		out = append(append(out, ctrl(5, 1)...), 2)
+		out = append(out, str("binary_format_minor_version")...)
+		out = append(out, ctrl(5, 0)...)
+		out = append(out, str("build_epoch")...)
+		out = append(append(out, ctrl(9, 8)...), binary.BigEndian.AppendUint64(nil, 1700000000)...)
+		out = append(out, str("description")...)
+		out = append(out, ctrl(7, 2)...)
+		out = append(append(out, str("en")...), str("Test Database")...)
+		out = append(append(out, str("zh")...), str("Test Database Chinese")...)
+
+		if err := os.WriteFile(fmt.Sprintf("test-ipv4-%d.mmdb", size), out, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
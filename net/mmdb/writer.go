@@ -0,0 +1,234 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package mmdb
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/netip"
+	"slices"
+	"time"
+)
+
+// Writer builds a database from prefixes and their records. Where
+// prefixes overlap, the longest prefix containing an address supplies
+// its record, whatever the order of insertion.
+type Writer struct {
+	DatabaseType string            // the kind of data the database holds
+	Languages    []string          // the locales the database covers
+	Description  map[string]string // descriptions, keyed by locale
+	// RecordSize is the size of a search tree record in bits, 24, 28
+	// or 32, or 0 to use the smallest size that fits.
+	RecordSize int
+	// BuildTime is recorded in the metadata. The zero value stands for
+	// the time the database is written.
+	BuildTime time.Time
+
+	ipVersion int
+	records   map[netip.Prefix][]byte // encoded records by tree prefix
+}
+
+// NewWriter returns a Writer for an IPv4 or, if ipVersion is 6, an
+// IPv6 database. IPv6 databases hold IPv4 prefixes under ::/96, where
+// Reader.Lookup expects them.
+func NewWriter(ipVersion int) (*Writer, error) {
+	if ipVersion != 4 && ipVersion != 6 {
+		return nil, fmt.Errorf("mmdb: unsupported IP version %d", ipVersion)
+	}
+	return &Writer{ipVersion: ipVersion, records: map[netip.Prefix][]byte{}}, nil
+}
+
+// Insert associates record with p, replacing any record already
+// associated with it. The host bits of p are ignored. Records may hold
+// the types Reader.Lookup returns, along with map[string]string and
+// []string, and no more values than Reader.Lookup will decode.
+func (w *Writer) Insert(p netip.Prefix, record any) error {
+	if !p.IsValid() {
+		return errors.New("invalid netip.Prefix")
+	}
+	if p.Addr().Is6() && w.ipVersion == 4 {
+		return fmt.Errorf("mmdb: cannot insert IPv6 prefix %s in an IPv4 database", p)
+	}
+	b, err := encode(nil, record, 0)
+	if err != nil {
+		return fmt.Errorf("mmdb: %s: %w", p, err)
+	}
+	if n := countValues(record); n > maxValues {
+		return fmt.Errorf("mmdb: %s: record holds %d values, more than %d", p, n, maxValues)
+	}
+	w.records[w.treePrefix(p)] = b
+	return nil
+}
+
+// InsertMap inserts every prefix of records with its record.
+func (w *Writer) InsertMap(records map[netip.Prefix]any) error {
+	for _, p := range slices.SortedFunc(maps.Keys(records), comparePrefixes) {
+		if err := w.Insert(p, records[p]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// treePrefix returns the prefix under which p is stored in the search
+// tree.
+func (w *Writer) treePrefix(p netip.Prefix) netip.Prefix {
+	p = p.Masked()
+	if p.Addr().Is4() && w.ipVersion == 6 {
+		var a [16]byte
+		copy(a[12:], p.Addr().AsSlice())
+		return netip.PrefixFrom(netip.AddrFrom16(a), p.Bits()+96)
+	}
+	return p
+}
+
+// comparePrefixes orders prefixes by length, then by address.
+func comparePrefixes(p, q netip.Prefix) int {
+	if c := cmp.Compare(p.Bits(), q.Bits()); c != 0 {
+		return c
+	}
+	return p.Addr().Compare(q.Addr())
+}
+
+// wnode is a search tree node under construction. Each slot leads to a
+// node, to a record, or, if both are unset, to nothing.
+type wnode struct {
+	slot [2]struct {
+		node *wnode
+		data int // 1 + the index of the record, or 0
+	}
+	id int
+}
+
+// WriteTo writes the database to out.
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	// Insert the shortest prefixes first, so that each later prefix
+	// only ever splits a record slot and overrides part of it.
+	root := &wnode{}
+	var data [][]byte
+	for _, p := range slices.SortedFunc(maps.Keys(w.records), comparePrefixes) {
+		data = append(data, w.records[p])
+		insert(root, p, len(data))
+	}
+
+	// Number the nodes breadth first, and lay out the data section,
+	// storing each distinct record once.
+	nodes := []*wnode{root}
+	for i := 0; i < len(nodes); i++ {
+		nodes[i].id = i
+		for _, s := range nodes[i].slot {
+			if s.node != nil {
+				nodes = append(nodes, s.node)
+			}
+		}
+	}
+	var section []byte
+	offsets := make([]int, len(data))
+	seen := map[string]int{}
+	for i, b := range data {
+		off, ok := seen[string(b)]
+		if !ok {
+			off = len(section)
+			seen[string(b)] = off
+			section = append(section, b...)
+		}
+		offsets[i] = off
+	}
+
+	nodeCount := len(nodes)
+	recordSize := w.RecordSize
+	limit := uint64(nodeCount) + dataSectionSeparator + uint64(len(section))
+	if recordSize == 0 {
+		recordSize = 24
+		for recordSize < 32 && limit >= 1<<recordSize {
+			recordSize += 4
+		}
+	}
+	if recordSize != 24 && recordSize != 28 && recordSize != 32 {
+		return 0, fmt.Errorf("mmdb: unsupported record size %d", recordSize)
+	}
+	if limit >= 1<<recordSize {
+		return 0, fmt.Errorf("mmdb: database too large for %d-bit records", recordSize)
+	}
+
+	buf := make([]byte, 0, nodeCount*recordSize/4+dataSectionSeparator+len(section))
+	for _, n := range nodes {
+		var rec [2]uint32
+		for i, s := range n.slot {
+			switch {
+			case s.node != nil:
+				rec[i] = uint32(s.node.id)
+			case s.data > 0:
+				rec[i] = uint32(nodeCount + dataSectionSeparator + offsets[s.data-1])
+			default:
+				rec[i] = uint32(nodeCount)
+			}
+		}
+		buf = appendNode(buf, rec, recordSize)
+	}
+	buf = append(buf, make([]byte, dataSectionSeparator)...)
+	buf = append(buf, section...)
+	buf = append(buf, metadataMarker...)
+
+	built := w.BuildTime
+	if built.IsZero() {
+		built = time.Now()
+	}
+	md := map[string]any{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(recordSize),
+		"ip_version":                  uint16(w.ipVersion),
+		"database_type":               w.DatabaseType,
+		"languages":                   w.Languages,
+		"description":                 w.Description,
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(max(built.Unix(), 0)),
+	}
+	buf, err := encode(buf, md, 0)
+	if err != nil {
+		return 0, err
+	}
+	n, err := out.Write(buf)
+	return int64(n), err
+}
+
+// insert adds prefix p with record data to the tree rooted at root.
+func insert(root *wnode, p netip.Prefix, data int) {
+	bits, key := p.Bits(), p.Addr().AsSlice()
+	if bits == 0 {
+		root.slot[0].data, root.slot[1].data = data, data
+		return
+	}
+	n := root
+	for i := range bits - 1 {
+		s := &n.slot[key[i/8]>>(7-i%8)&1]
+		if s.node == nil {
+			s.node = &wnode{}
+			s.node.slot[0].data, s.node.slot[1].data = s.data, s.data
+			s.data = 0
+		}
+		n = s.node
+	}
+	n.slot[key[(bits-1)/8]>>(7-(bits-1)%8)&1].data = data
+}
+
+// appendNode appends a search tree node holding records rec.
+func appendNode(b []byte, rec [2]uint32, recordSize int) []byte {
+	switch recordSize {
+	case 24:
+		return append(b, byte(rec[0]>>16), byte(rec[0]>>8), byte(rec[0]),
+			byte(rec[1]>>16), byte(rec[1]>>8), byte(rec[1]))
+	case 28:
+		return append(b, byte(rec[0]>>16), byte(rec[0]>>8), byte(rec[0]),
+			byte(rec[0]>>24)<<4|byte(rec[1]>>24),
+			byte(rec[1]>>16), byte(rec[1]>>8), byte(rec[1]))
+	default:
+		return binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(b, rec[0]), rec[1])
+	}
+}
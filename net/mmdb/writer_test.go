@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package mmdb
+
+import (
+	"bytes"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// sampleRecords is a small GeoIP-style data set.
+func sampleRecords() map[netip.Prefix]any {
+	return map[netip.Prefix]any{
+		netip.MustParsePrefix("192.0.2.0/24"):    map[string]any{"country": "AU", "asn": uint32(64496)},
+		netip.MustParsePrefix("192.0.2.128/25"):  map[string]any{"country": "NZ", "asn": uint32(64497)},
+		netip.MustParsePrefix("198.51.100.7/32"): map[string]any{"country": "AU", "asn": uint32(64496)},
+		netip.MustParsePrefix("10.0.0.0/8"):      map[string]any{"private": true},
+		netip.MustParsePrefix("2001:db8::/32"):   map[string]any{"country": "DE", "names": map[string]string{"en": "Germany", "de": "Deutschland"}},
+		netip.MustParsePrefix("2001:db8:1::/48"): map[string]any{"country": "AT", "weight": 0.5},
+	}
+}
+
+// build writes a database for records and opens it.
+func build(t *testing.T, w *Writer, records map[netip.Prefix]any) *Reader {
+	t.Helper()
+	if err := w.InsertMap(records); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	r, err := FromBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	testpairs := []struct {
+		addr    string
+		network string
+		country any
+	}{
+		{addr: "192.0.2.1", network: "192.0.2.0/25", country: "AU"},
+		{addr: "192.0.2.200", network: "192.0.2.128/25", country: "NZ"},
+		{addr: "198.51.100.7", network: "198.51.100.7/32", country: "AU"},
+		{addr: "10.1.2.3", network: "10.0.0.0/8", country: nil},
+		{addr: "2001:db8:2::1", network: "2001:db8:2::/47", country: "DE"},
+		{addr: "2001:db8:1:2::1", network: "2001:db8:1::/48", country: "AT"},
+	}
+
+	for _, size := range []int{0, 24, 28, 32} {
+		w, err := NewWriter(6)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.RecordSize = size
+		r := build(t, w, sampleRecords())
+		for _, pair := range testpairs {
+			res, err := r.Lookup(netip.MustParseAddr(pair.addr))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !res.Found {
+				t.Errorf("%d: %s: expected a record", size, pair.addr)
+				continue
+			}
+			if res.Network != netip.MustParsePrefix(pair.network) {
+				t.Errorf("%d: %s: expected %s, got %s", size, pair.addr, pair.network, res.Network)
+			}
+			if actual := res.Record.(map[string]any)["country"]; actual != pair.country {
+				t.Errorf("%d: %s: expected %v, got %v", size, pair.addr, pair.country, actual)
+			}
+		}
+	}
+}
+
+func TestWriterInsertionOrder(t *testing.T) {
+	// A covering prefix inserted last does not hide the prefixes within
+	// it, and a repeated prefix takes the latest record.
+	w, _ := NewWriter(4)
+	for _, e := range []struct {
+		prefix string
+		record string
+	}{
+		{"192.0.2.64/26", "inner"},
+		{"192.0.2.0/24", "stale"},
+		{"192.0.2.0/24", "outer"},
+	} {
+		if err := w.Insert(netip.MustParsePrefix(e.prefix), e.record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := build(t, w, nil)
+	for addr, expected := range map[string]string{"192.0.2.65": "inner", "192.0.2.1": "outer", "192.0.2.255": "outer"} {
+		if res, _ := r.Lookup(netip.MustParseAddr(addr)); res.Record != expected {
+			t.Errorf("%s: expected %s, got %v", addr, expected, res.Record)
+		}
+	}
+}
+
+func TestWriterDeduplicates(t *testing.T) {
+	record := map[string]any{"country": "AU", "names": []string{"Australia", "Straya"}}
+	w, _ := NewWriter(4)
+	for i := range 64 {
+		if err := w.Insert(netip.PrefixFrom(netip.AddrFrom4([4]byte{10, byte(i), 0, 0}), 16), record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	enc, _ := encode(nil, record, 0)
+	if n := bytes.Count(buf.Bytes(), enc); n != 1 {
+		t.Errorf("expected the record once, got %d copies", n)
+	}
+}
+
+func TestWriterMetadata(t *testing.T) {
+	w, _ := NewWriter(4)
+	w.DatabaseType = "Example-City"
+	w.Languages = []string{"en", "de"}
+	w.Description = map[string]string{"en": "An example"}
+	w.BuildTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := build(t, w, map[netip.Prefix]any{netip.MustParsePrefix("0.0.0.0/0"): "everything"})
+
+	expected := Metadata{
+		NodeCount:    1,
+		RecordSize:   24,
+		IPVersion:    4,
+		DatabaseType: "Example-City",
+		Languages:    []string{"en", "de"},
+		Description:  map[string]string{"en": "An example"},
+		MajorVersion: 2,
+		BuildTime:    w.BuildTime,
+	}
+	if !reflect.DeepEqual(r.Metadata, expected) {
+		t.Errorf("expected %+v, got %+v", expected, r.Metadata)
+	}
+}
+
+func TestWriterLargestRecord(t *testing.T) {
+	// The largest record Insert accepts is one Lookup will decode.
+	w, _ := NewWriter(4)
+	record := make([]string, maxValues-1)
+	r := build(t, w, map[netip.Prefix]any{netip.MustParsePrefix("192.0.2.0/24"): record})
+	res, err := r.Lookup(netip.MustParseAddr("192.0.2.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, ok := res.Record.([]any); !ok || len(a) != len(record) {
+		t.Errorf("unexpected record of type %T", res.Record)
+	}
+}
+
+func TestWriterErrors(t *testing.T) {
+	if _, err := NewWriter(5); err == nil {
+		t.Error("expected error")
+	}
+	w, _ := NewWriter(4)
+	if err := w.Insert(netip.MustParsePrefix("2001:db8::/32"), "x"); err == nil {
+		t.Error("expected error")
+	}
+	if err := w.Insert(netip.Prefix{}, "x"); err == nil {
+		t.Error("expected error")
+	}
+	if err := w.Insert(netip.MustParsePrefix("192.0.2.0/24"), 42); err == nil {
+		t.Error("expected error")
+	}
+	if err := w.Insert(netip.MustParsePrefix("192.0.2.0/24"), make([]string, maxValues)); err == nil {
+		t.Error("expected error")
+	}
+	w.RecordSize = 20
+	if _, err := w.WriteTo(&bytes.Buffer{}); err == nil {
+		t.Error("expected error")
+	}
+}
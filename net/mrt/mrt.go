@@ -0,0 +1,450 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+// Package mrt reads BGP routing table dumps in the MRT TABLE_DUMP_V2
+// format of RFC 6396, including the add-path subtypes of RFC 8050.
+package mrt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MRT record types and TABLE_DUMP_V2 subtypes.
+const (
+	typeTableDumpV2 = 13
+
+	subtypePeerIndexTable          = 1
+	subtypeRIBIPv4Unicast          = 2
+	subtypeRIBIPv4Multicast        = 3
+	subtypeRIBIPv6Unicast          = 4
+	subtypeRIBIPv6Multicast        = 5
+	subtypeRIBGeneric              = 6
+	subtypeRIBIPv4UnicastAddPath   = 8
+	subtypeRIBIPv4MulticastAddPath = 9
+	subtypeRIBIPv6UnicastAddPath   = 10
+	subtypeRIBIPv6MulticastAddPath = 11
+	subtypeRIBGenericAddPath       = 12
+)
+
+// Address families, BGP path attributes and attribute flags.
+const (
+	afiIPv4       = 1
+	afiIPv6       = 2
+	safiUnicast   = 1
+	safiMulticast = 2
+
+	attrASPath      = 2
+	attrNextHop     = 3
+	attrMPReachNLRI = 14
+
+	attrFlagExtendedLength = 0x10
+)
+
+// ribSubtypes describes the AFI/SAFI-specific RIB subtypes.
+var ribSubtypes = map[uint16]struct{ is4, multicast, addPath bool }{
+	subtypeRIBIPv4Unicast:          {true, false, false},
+	subtypeRIBIPv4Multicast:        {true, true, false},
+	subtypeRIBIPv6Unicast:          {false, false, false},
+	subtypeRIBIPv6Multicast:        {false, true, false},
+	subtypeRIBIPv4UnicastAddPath:   {true, false, true},
+	subtypeRIBIPv4MulticastAddPath: {true, true, true},
+	subtypeRIBIPv6UnicastAddPath:   {false, false, true},
+	subtypeRIBIPv6MulticastAddPath: {false, true, true},
+}
+
+// headerLen is the length of the MRT common header, and maxRecordLen
+// bounds the length of a record, guarding against corrupt input.
+const (
+	headerLen    = 12
+	maxRecordLen = 1 << 24
+)
+
+// SegmentType is the type of an AS_PATH segment.
+type SegmentType uint8
+
+// AS_PATH segment types, from RFC 4271 and RFC 5065.
+const (
+	ASSet            SegmentType = 1
+	ASSequence       SegmentType = 2
+	ASConfedSequence SegmentType = 3
+	ASConfedSet      SegmentType = 4
+)
+
+// Segment is one segment of an AS_PATH.
+type Segment struct {
+	Type SegmentType
+	ASNs []uint32
+}
+
+// ASPath is the AS_PATH attribute of a route, nearest AS first.
+type ASPath []Segment
+
+// Origin returns the AS that originated the route: the last AS of a
+// path ending in an AS_SEQUENCE, or the sole member of a final AS_SET.
+// There is none if the path is empty, ends in a larger AS_SET, or ends
+// in a confederation segment.
+func (p ASPath) Origin() (uint32, bool) {
+	if len(p) == 0 {
+		return 0, false
+	}
+	last := p[len(p)-1]
+	switch {
+	case last.Type == ASSequence && len(last.ASNs) > 0:
+		return last.ASNs[len(last.ASNs)-1], true
+	case last.Type == ASSet && len(last.ASNs) == 1:
+		return last.ASNs[0], true
+	default:
+		return 0, false
+	}
+}
+
+// String returns p in the customary notation, with AS_SETs in braces,
+// confederation sequences in parentheses and confederation sets in
+// brackets, e.g. "64496 (64512 64513) 64497 {64498,64499}".
+func (p ASPath) String() string {
+	var parts []string
+	for _, seg := range p {
+		asns := make([]string, len(seg.ASNs))
+		for i, asn := range seg.ASNs {
+			asns[i] = strconv.FormatUint(uint64(asn), 10)
+		}
+		switch seg.Type {
+		case ASSet:
+			parts = append(parts, "{"+strings.Join(asns, ",")+"}")
+		case ASConfedSequence:
+			parts = append(parts, "("+strings.Join(asns, " ")+")")
+		case ASConfedSet:
+			parts = append(parts, "["+strings.Join(asns, ",")+"]")
+		default:
+			parts = append(parts, asns...)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Peer is a BGP peer from which the collector received routes.
+type Peer struct {
+	BGPID netip.Addr // the BGP identifier
+	Addr  netip.Addr // the peering address
+	AS    uint32     // the peer AS
+}
+
+// RIBEntry is a route to a prefix as received from one peer.
+type RIBEntry struct {
+	PeerIndex  int       // the index of the peer in Reader.Peers
+	Peer       Peer      // the peer
+	Originated time.Time // when the route was received
+	PathID     uint32    // the add-path identifier, or 0
+	ASPath     ASPath    // the AS_PATH, if present
+	NextHop    netip.Addr
+}
+
+// RIB is the set of routes to one prefix.
+type RIB struct {
+	Time      time.Time    // when the dump was taken
+	Sequence  uint32       // the position of the record within the dump
+	Prefix    netip.Prefix // the masked prefix
+	Multicast bool         // whether the routes are for multicast
+	Entries   []RIBEntry
+}
+
+// Reader reads the RIB records of a TABLE_DUMP_V2 file, which must be
+// uncompressed. Records of other types and unsupported subtypes are
+// skipped, and the PEER_INDEX_TABLE is consumed to resolve the peer of
+// each RIBEntry.
+type Reader struct {
+	r         *bufio.Reader
+	peers     []Peer
+	collector netip.Addr
+	view      string
+}
+
+// NewReader returns a Reader reading from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Peers returns the peers of the most recent PEER_INDEX_TABLE.
+func (r *Reader) Peers() []Peer {
+	return r.peers
+}
+
+// Collector returns the BGP identifier of the collector, once the
+// PEER_INDEX_TABLE has been read.
+func (r *Reader) Collector() netip.Addr {
+	return r.collector
+}
+
+// View returns the view name of the dump, which is often empty.
+func (r *Reader) View() string {
+	return r.view
+}
+
+// Next returns the next RIB record. It returns io.EOF at the end of
+// the input, and io.ErrUnexpectedEOF if the input ends within a
+// record.
+func (r *Reader) Next() (*RIB, error) {
+	for {
+		var hdr [headerLen]byte
+		if _, err := io.ReadFull(r.r, hdr[:]); err != nil {
+			return nil, err
+		}
+		ts := time.Unix(int64(binary.BigEndian.Uint32(hdr[0:])), 0).UTC()
+		typ := binary.BigEndian.Uint16(hdr[4:])
+		subtype := binary.BigEndian.Uint16(hdr[6:])
+		n := binary.BigEndian.Uint32(hdr[8:])
+		if n > maxRecordLen {
+			return nil, fmt.Errorf("mrt: record length %d exceeds %d", n, maxRecordLen)
+		}
+		body := make([]byte, n)
+		if _, err := io.ReadFull(r.r, body); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if typ != typeTableDumpV2 {
+			continue
+		}
+
+		d := &decoder{b: body}
+		var rib *RIB
+		switch subtype {
+		case subtypePeerIndexTable:
+			r.readPeerIndexTable(d)
+		case subtypeRIBIPv4Unicast, subtypeRIBIPv4Multicast, subtypeRIBIPv6Unicast, subtypeRIBIPv6Multicast,
+			subtypeRIBIPv4UnicastAddPath, subtypeRIBIPv4MulticastAddPath, subtypeRIBIPv6UnicastAddPath, subtypeRIBIPv6MulticastAddPath:
+			k := ribSubtypes[subtype]
+			rib = r.readRIB(d, k.is4, k.multicast, k.addPath)
+		case subtypeRIBGeneric, subtypeRIBGenericAddPath:
+			rib = r.readRIBGeneric(d, subtype == subtypeRIBGenericAddPath)
+		default:
+			continue
+		}
+		if d.err != nil {
+			return nil, fmt.Errorf("mrt: subtype %d record: %w", subtype, d.err)
+		}
+		if rib != nil {
+			rib.Time = ts
+			return rib, nil
+		}
+	}
+}
+
+// readPeerIndexTable reads a PEER_INDEX_TABLE record.
+func (r *Reader) readPeerIndexTable(d *decoder) {
+	r.collector = d.addr(true)
+	r.view = string(d.bytes(int(d.u16())))
+	peers := make([]Peer, 0, d.u16())
+	for i := 0; i < cap(peers) && d.err == nil; i++ {
+		typ := d.u8()
+		p := Peer{BGPID: d.addr(true), Addr: d.addr(typ&1 == 0)}
+		if typ&2 != 0 {
+			p.AS = d.u32()
+		} else {
+			p.AS = uint32(d.u16())
+		}
+		peers = append(peers, p)
+	}
+	if d.err == nil {
+		r.peers = peers
+	}
+}
+
+// readRIB reads an AFI/SAFI-specific RIB record.
+func (r *Reader) readRIB(d *decoder, is4, multicast, addPath bool) *RIB {
+	rib := &RIB{Sequence: d.u32(), Multicast: multicast}
+	rib.Prefix = d.prefix(is4)
+	rib.Entries = r.readEntries(d, addPath)
+	return rib
+}
+
+// readRIBGeneric reads a RIB_GENERIC record, returning nil if it is not
+// for IPv4 or IPv6 unicast or multicast.
+func (r *Reader) readRIBGeneric(d *decoder, addPath bool) *RIB {
+	seq, afi, safi := d.u32(), d.u16(), d.u8()
+	if (afi != afiIPv4 && afi != afiIPv6) || (safi != safiUnicast && safi != safiMulticast) {
+		return nil
+	}
+	rib := &RIB{Sequence: seq, Multicast: safi == safiMulticast}
+	rib.Prefix = d.prefix(afi == afiIPv4)
+	rib.Entries = r.readEntries(d, addPath)
+	return rib
+}
+
+// readEntries reads the RIB entries ending a RIB record.
+func (r *Reader) readEntries(d *decoder, addPath bool) []RIBEntry {
+	entries := make([]RIBEntry, 0, d.u16())
+	for i := 0; i < cap(entries) && d.err == nil; i++ {
+		e := RIBEntry{PeerIndex: int(d.u16())}
+		e.Originated = time.Unix(int64(d.u32()), 0).UTC()
+		if addPath {
+			e.PathID = d.u32()
+		}
+		attrs := d.bytes(int(d.u16()))
+		if d.err != nil {
+			break
+		}
+		if err := readAttributes(attrs, &e); err != nil {
+			d.err = err
+			break
+		}
+		if e.PeerIndex >= len(r.peers) {
+			d.err = fmt.Errorf("peer index %d out of range", e.PeerIndex)
+			break
+		}
+		e.Peer = r.peers[e.PeerIndex]
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// readAttributes decodes the path attributes of interest into e.
+func readAttributes(b []byte, e *RIBEntry) error {
+	d := &decoder{b: b}
+	for d.err == nil && len(d.b) > 0 {
+		flags, typ := d.u8(), d.u8()
+		n := int(d.u8())
+		if flags&attrFlagExtendedLength != 0 {
+			n = n<<8 | int(d.u8())
+		}
+		a := &decoder{b: d.bytes(n)}
+		if d.err != nil {
+			break
+		}
+		switch typ {
+		case attrASPath:
+			e.ASPath = readASPath(a)
+		case attrNextHop:
+			e.NextHop = a.addr(true)
+		case attrMPReachNLRI:
+			e.NextHop = readMPNextHop(a)
+		}
+		if a.err != nil {
+			return fmt.Errorf("attribute %d: %w", typ, a.err)
+		}
+	}
+	return d.err
+}
+
+// readASPath decodes an AS_PATH attribute. TABLE_DUMP_V2 always uses
+// 4-byte AS numbers.
+func readASPath(d *decoder) ASPath {
+	var path ASPath
+	for d.err == nil && len(d.b) > 0 {
+		seg := Segment{Type: SegmentType(d.u8())}
+		seg.ASNs = make([]uint32, 0, d.u8())
+		for i := 0; i < cap(seg.ASNs) && d.err == nil; i++ {
+			seg.ASNs = append(seg.ASNs, d.u32())
+		}
+		path = append(path, seg)
+	}
+	return path
+}
+
+// readMPNextHop extracts the next hop from an MP_REACH_NLRI attribute.
+// RFC 6396 abbreviates the attribute to the next hop length and
+// address, but some implementations write it in full.
+func readMPNextHop(d *decoder) netip.Addr {
+	if len(d.b) > 0 && int(d.b[0]) != len(d.b)-1 {
+		d.bytes(3) // AFI and SAFI
+	}
+	nh := d.bytes(int(d.u8()))
+	switch {
+	case d.err != nil:
+		return netip.Addr{}
+	case len(nh) == 4:
+		return netip.AddrFrom4([4]byte(nh))
+	case len(nh) == 16 || len(nh) == 32: // global, then link-local
+		return netip.AddrFrom16([16]byte(nh[:16]))
+	default:
+		d.err = fmt.Errorf("invalid next hop length %d", len(nh))
+		return netip.Addr{}
+	}
+}
+
+// errShort reports a record or field ending early.
+var errShort = errors.New("truncated")
+
+// decoder consumes big-endian fields from a byte slice. After the
+// first error, every method returns zero values.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > len(d.b) {
+		d.err = errShort
+		return nil
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b
+}
+
+func (d *decoder) u8() uint8 {
+	if b := d.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) u16() uint16 {
+	if b := d.bytes(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) u32() uint32 {
+	if b := d.bytes(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+// addr reads an IPv4 or IPv6 address.
+func (d *decoder) addr(is4 bool) netip.Addr {
+	if is4 {
+		if b := d.bytes(4); b != nil {
+			return netip.AddrFrom4([4]byte(b))
+		}
+	} else if b := d.bytes(16); b != nil {
+		return netip.AddrFrom16([16]byte(b))
+	}
+	return netip.Addr{}
+}
+
+// prefix reads a prefix length and the significant bytes of a prefix.
+func (d *decoder) prefix(is4 bool) netip.Prefix {
+	bits, width := int(d.u8()), 128
+	if is4 {
+		width = 32
+	}
+	if d.err == nil && bits > width {
+		d.err = fmt.Errorf("invalid prefix length %d", bits)
+	}
+	b := d.bytes((bits + 7) / 8)
+	if d.err != nil {
+		return netip.Prefix{}
+	}
+	var a [16]byte
+	copy(a[:], b)
+	addr := netip.AddrFrom16(a)
+	if is4 {
+		addr = netip.AddrFrom4([4]byte(a[:4]))
+	}
+	return netip.PrefixFrom(addr, bits).Masked()
+}
@@ -0,0 +1,199 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package mrt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/netip"
+	"os"
+	"testing"
+	"time"
+)
+
+// openFixture returns a Reader for a file in testdata.
+func openFixture(t *testing.T, name string) *Reader {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return NewReader(f)
+}
+
+func TestReader(t *testing.T) {
+	r := openFixture(t, "rib.mrt")
+	testpairs := []struct {
+		prefix    string
+		multicast bool
+		paths     []string
+		nextHops  []string
+		peers     []uint32
+	}{
+		{prefix: "10.0.0.0/8", paths: []string{"64500 64496", "64501 64497"}, nextHops: []string{"192.0.2.1", "192.0.2.3"}, peers: []uint32{64500, 64501}},
+		{prefix: "198.51.100.0/24", paths: []string{"64500 64510"}, nextHops: []string{"192.0.2.1"}, peers: []uint32{64500}},
+		{prefix: "198.51.100.128/25", paths: []string{"64500 64511"}, nextHops: []string{"192.0.2.1"}, peers: []uint32{64500}},
+		{prefix: "203.0.113.0/24", paths: []string{"64500 {64512,64513}"}, nextHops: []string{"192.0.2.1"}, peers: []uint32{64500}},
+		{prefix: "192.0.2.0/24", multicast: true, paths: []string{"64500 64520"}, nextHops: []string{"192.0.2.1"}, peers: []uint32{64500}},
+		{prefix: "2001:db8::/32", paths: []string{"4200000000 64496"}, nextHops: []string{"2001:db8::2"}, peers: []uint32{4200000000}},
+		{prefix: "2001:db8:8000::/33", paths: []string{"(65000 65001) 4200000000 64499"}, nextHops: []string{"2001:db8::2"}, peers: []uint32{4200000000}},
+	}
+
+	for i, pair := range testpairs {
+		rib, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rib.Sequence != uint32(i) {
+			t.Errorf("expected sequence %d, got %d", i, rib.Sequence)
+		}
+		if rib.Prefix != netip.MustParsePrefix(pair.prefix) {
+			t.Errorf("expected %s, got %s", pair.prefix, rib.Prefix)
+		}
+		if rib.Multicast != pair.multicast {
+			t.Errorf("%s: expected multicast %v, got %v", pair.prefix, pair.multicast, rib.Multicast)
+		}
+		if !rib.Time.Equal(time.Unix(1700000000, 0)) {
+			t.Errorf("%s: unexpected dump time %v", pair.prefix, rib.Time)
+		}
+		if len(rib.Entries) != len(pair.paths) {
+			t.Fatalf("%s: expected %d entries, got %d", pair.prefix, len(pair.paths), len(rib.Entries))
+		}
+		for j, e := range rib.Entries {
+			if actual := e.ASPath.String(); actual != pair.paths[j] {
+				t.Errorf("%s: expected path %q, got %q", pair.prefix, pair.paths[j], actual)
+			}
+			if actual := e.NextHop.String(); actual != pair.nextHops[j] {
+				t.Errorf("%s: expected next hop %s, got %s", pair.prefix, pair.nextHops[j], actual)
+			}
+			if e.Peer.AS != pair.peers[j] {
+				t.Errorf("%s: expected peer AS %d, got %d", pair.prefix, pair.peers[j], e.Peer.AS)
+			}
+			if !e.Originated.Equal(time.Unix(1699990000, 0)) {
+				t.Errorf("%s: unexpected originated time %v", pair.prefix, e.Originated)
+			}
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+
+	if r.Collector() != netip.MustParseAddr("192.0.2.254") || r.View() != "test" {
+		t.Errorf("unexpected collector %v and view %q", r.Collector(), r.View())
+	}
+	expected := []Peer{
+		{BGPID: netip.MustParseAddr("192.0.2.1"), Addr: netip.MustParseAddr("192.0.2.1"), AS: 64500},
+		{BGPID: netip.MustParseAddr("192.0.2.2"), Addr: netip.MustParseAddr("2001:db8::2"), AS: 4200000000},
+		{BGPID: netip.MustParseAddr("192.0.2.3"), Addr: netip.MustParseAddr("192.0.2.3"), AS: 64501},
+	}
+	if peers := r.Peers(); len(peers) != len(expected) || peers[0] != expected[0] || peers[1] != expected[1] || peers[2] != expected[2] {
+		t.Errorf("expected peers %v, got %v", expected, peers)
+	}
+}
+
+func TestReaderAddPath(t *testing.T) {
+	r := openFixture(t, "addpath.mrt")
+	rib, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rib.Prefix != netip.MustParsePrefix("192.0.2.0/24") || len(rib.Entries) != 2 {
+		t.Fatalf("unexpected record %+v", rib)
+	}
+	for i, e := range rib.Entries {
+		if e.PathID != uint32(i+1) {
+			t.Errorf("expected path ID %d, got %d", i+1, e.PathID)
+		}
+	}
+	rib, err = r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rib.Prefix != netip.MustParsePrefix("::/0") || rib.Entries[0].PathID != 7 || rib.Entries[0].NextHop != netip.MustParseAddr("2001:db8::1") {
+		t.Errorf("unexpected record %+v", rib)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	data, err := os.ReadFile("testdata/rib.mrt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every truncation either ends cleanly at a record boundary or
+	// reports an error; none panics.
+	for n := range len(data) {
+		r := NewReader(bytes.NewReader(data[:n]))
+		for err == nil {
+			_, err = r.Next()
+		}
+		if err != io.EOF && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%d bytes: expected io.EOF or io.ErrUnexpectedEOF, got %v", n, err)
+		}
+		err = nil
+	}
+
+	// A RIB record preceding the PEER_INDEX_TABLE refers to no peer.
+	addpath, err := os.ReadFile("testdata/addpath.mrt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const peerIndexLen = 12 + 25
+	r := NewReader(bytes.NewReader(addpath[peerIndexLen:]))
+	if _, err := r.Next(); err == nil {
+		t.Error("expected error")
+	}
+
+	// A corrupt attribute length overruns the entry.
+	corrupt := bytes.Clone(addpath)
+	corrupt[peerIndexLen+12+4+1+3+2+2+4+4+1] = 0xff
+	r = NewReader(bytes.NewReader(corrupt))
+	if _, err := r.Next(); err == nil {
+		t.Error("expected error")
+	}
+
+	// An oversized record is refused without reading it.
+	r = NewReader(bytes.NewReader([]byte{0, 0, 0, 0, 0, 13, 0, 2, 0xff, 0xff, 0xff, 0xff}))
+	if _, err := r.Next(); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestASPathOrigin(t *testing.T) {
+	testpairs := []struct {
+		path     ASPath
+		expected uint32
+		ok       bool
+	}{
+		{path: ASPath{{ASSequence, []uint32{64500, 64496}}}, expected: 64496, ok: true},
+		{path: ASPath{{ASSequence, []uint32{64500}}, {ASSet, []uint32{64497}}}, expected: 64497, ok: true},
+		{path: ASPath{{ASSequence, []uint32{64500}}, {ASSet, []uint32{64497, 64498}}}},
+		{path: ASPath{{ASConfedSequence, []uint32{65000}}}},
+		{path: ASPath{{ASSequence, nil}}},
+		{path: nil},
+	}
+
+	for _, pair := range testpairs {
+		actual, ok := pair.path.Origin()
+		if actual != pair.expected || ok != pair.ok {
+			t.Errorf("%v: expected %d (%v), got %d (%v)", pair.path, pair.expected, pair.ok, actual, ok)
+		}
+	}
+}
+
+func TestASPathString(t *testing.T) {
+	path := ASPath{
+		{ASSequence, []uint32{64496}},
+		{ASConfedSequence, []uint32{64512, 64513}},
+		{ASConfedSet, []uint32{64514, 64515}},
+		{ASSequence, []uint32{64497}},
+		{ASSet, []uint32{64498, 64499}},
+	}
+	expected := "64496 (64512 64513) [64514,64515] 64497 {64498,64499}"
+	if actual := path.String(); actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package mrt
+
+import (
+	"io"
+	"slices"
+
+	ynet "github.com/yesmar/y/net"
+)
+
+// OriginTable reads every unicast RIB record from r and returns a
+// table mapping each prefix to the sorted, distinct ASes originating
+// it. A prefix has several origins if peers disagree, and none if
+// every path to it ends in an AS_SET; such prefixes are still present,
+// so that lookups do not fall through to a covering prefix. Use the
+// table's Lookup method for longest-match lookups.
+func OriginTable(r *Reader) (*ynet.Table[[]uint32], error) {
+	t := &ynet.Table[[]uint32]{}
+	for {
+		rib, err := r.Next()
+		if err == io.EOF {
+			return t, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if rib.Multicast {
+			continue
+		}
+		origins, _ := t.Get(rib.Prefix)
+		for _, e := range rib.Entries {
+			if asn, ok := e.ASPath.Origin(); ok {
+				origins = append(origins, asn)
+			}
+		}
+		slices.Sort(origins)
+		if err := t.Insert(rib.Prefix, slices.Compact(origins)); err != nil {
+			return nil, err
+		}
+	}
+}
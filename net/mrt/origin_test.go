@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package mrt
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestOriginTable(t *testing.T) {
+	tbl, err := OriginTable(openFixture(t, "rib.mrt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tbl.Len() != 6 {
+		t.Errorf("expected 6 prefixes, got %d", tbl.Len())
+	}
+	testpairs := []struct {
+		addr     string
+		prefix   string
+		expected []uint32
+	}{
+		{addr: "10.20.30.40", prefix: "10.0.0.0/8", expected: []uint32{64496, 64497}},
+		{addr: "198.51.100.1", prefix: "198.51.100.0/24", expected: []uint32{64510}},
+		{addr: "198.51.100.200", prefix: "198.51.100.128/25", expected: []uint32{64511}},
+		{addr: "203.0.113.1", prefix: "203.0.113.0/24", expected: nil},
+		{addr: "2001:db8::1", prefix: "2001:db8::/32", expected: []uint32{64496}},
+		{addr: "2001:db8:8000::1", prefix: "2001:db8:8000::/33", expected: []uint32{64499}},
+	}
+
+	for _, pair := range testpairs {
+		p, origins, ok := tbl.Lookup(netip.MustParseAddr(pair.addr))
+		if !ok {
+			t.Errorf("%s: expected a match", pair.addr)
+			continue
+		}
+		if p != netip.MustParsePrefix(pair.prefix) {
+			t.Errorf("%s: expected %s, got %s", pair.addr, pair.prefix, p)
+		}
+		if !slices.Equal(origins, pair.expected) {
+			t.Errorf("%s: expected origins %v, got %v", pair.addr, pair.expected, origins)
+		}
+	}
+
+	// Multicast routes are left out, and nothing else covers these.
+	for _, addr := range []string{"192.0.2.1", "2002::1"} {
+		if p, _, ok := tbl.Lookup(netip.MustParseAddr(addr)); ok {
+			t.Errorf("%s: expected no match, got %s", addr, p)
+		}
+	}
+}
+
+func TestOriginTableAddPath(t *testing.T) {
+	tbl, err := OriginTable(openFixture(t, "addpath.mrt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, origins, _ := tbl.Lookup(netip.MustParseAddr("192.0.2.1")); !slices.Equal(origins, []uint32{64496, 64497}) {
+		t.Errorf("expected origins [64496 64497], got %v", origins)
+	}
+	if p, origins, _ := tbl.Lookup(netip.MustParseAddr("2002::1")); p.Bits() != 0 || !slices.Equal(origins, []uint32{64500}) {
+		t.Errorf("expected ::/0 from 64500, got %s from %v", p, origins)
+	}
+}
@@ -0,0 +1,234 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+//go:build ignore
+
+// gen writes the MRT fixtures used by the tests. Run it from this
+// directory with "go run gen.go".
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log"
+	"net/netip"
+	"os"
+)
+
+const (
+	dumpTime       = 1700000000
+	originatedTime = 1699990000
+)
+
+type buf struct{ bytes.Buffer }
+
+func (b *buf) u8(v uint8)   { b.WriteByte(v) }
+func (b *buf) u16(v uint16) { b.Write(binary.BigEndian.AppendUint16(nil, v)) }
+func (b *buf) u32(v uint32) { b.Write(binary.BigEndian.AppendUint32(nil, v)) }
+func (b *buf) addr(s string) {
+	b.Write(netip.MustParseAddr(s).AsSlice())
+}
+
+// record appends an MRT record to out.
+func record(out *bytes.Buffer, typ, subtype uint16, body []byte) {
+	var b buf
+	b.u32(dumpTime)
+	b.u16(typ)
+	b.u16(subtype)
+	b.u32(uint32(len(body)))
+	b.Write(body)
+	out.Write(b.Bytes())
+}
+
+type peer struct {
+	typ      uint8
+	id, addr string
+	as       uint32
+}
+
+func peerIndexTable(peers ...peer) []byte {
+	var b buf
+	b.addr("192.0.2.254")
+	b.u16(4)
+	b.WriteString("test")
+	b.u16(uint16(len(peers)))
+	for _, p := range peers {
+		b.u8(p.typ)
+		b.addr(p.id)
+		b.addr(p.addr)
+		if p.typ&2 != 0 {
+			b.u32(p.as)
+		} else {
+			b.u16(uint16(p.as))
+		}
+	}
+	return b.Bytes()
+}
+
+// segment is an AS_PATH segment.
+type segment struct {
+	typ  uint8
+	asns []uint32
+}
+
+func attr(flags, typ uint8, value []byte) []byte {
+	var b buf
+	b.u8(flags)
+	b.u8(typ)
+	if flags&0x10 != 0 {
+		b.u16(uint16(len(value)))
+	} else {
+		b.u8(uint8(len(value)))
+	}
+	b.Write(value)
+	return b.Bytes()
+}
+
+func asPath(flags uint8, segs ...segment) []byte {
+	var b buf
+	for _, s := range segs {
+		b.u8(s.typ)
+		b.u8(uint8(len(s.asns)))
+		for _, asn := range s.asns {
+			b.u32(asn)
+		}
+	}
+	return attr(flags, 2, b.Bytes())
+}
+
+func origin() []byte { return attr(0x40, 1, []byte{0}) }
+
+func nextHop(s string) []byte {
+	return attr(0x40, 3, netip.MustParseAddr(s).AsSlice())
+}
+
+// mpReach is the abbreviated MP_REACH_NLRI of RFC 6396.
+func mpReach(nh ...string) []byte {
+	var b buf
+	var addrs []byte
+	for _, s := range nh {
+		addrs = append(addrs, netip.MustParseAddr(s).AsSlice()...)
+	}
+	b.u8(uint8(len(addrs)))
+	b.Write(addrs)
+	return attr(0x80, 14, b.Bytes())
+}
+
+// mpReachFull is MP_REACH_NLRI with its AFI and SAFI, as some
+// implementations write it.
+func mpReachFull(nh ...string) []byte {
+	var b buf
+	b.u16(2)
+	b.u8(1)
+	var addrs []byte
+	for _, s := range nh {
+		addrs = append(addrs, netip.MustParseAddr(s).AsSlice()...)
+	}
+	b.u8(uint8(len(addrs)))
+	b.Write(addrs)
+	return attr(0x80, 14, b.Bytes())
+}
+
+type entry struct {
+	peer   uint16
+	pathID uint32
+	attrs  [][]byte
+}
+
+func prefix(b *buf, s string) {
+	p := netip.MustParsePrefix(s)
+	b.u8(uint8(p.Bits()))
+	b.Write(p.Addr().AsSlice()[:(p.Bits()+7)/8])
+}
+
+func entries(b *buf, addPath bool, es ...entry) {
+	b.u16(uint16(len(es)))
+	for _, e := range es {
+		b.u16(e.peer)
+		b.u32(originatedTime)
+		if addPath {
+			b.u32(e.pathID)
+		}
+		attrs := bytes.Join(e.attrs, nil)
+		b.u16(uint16(len(attrs)))
+		b.Write(attrs)
+	}
+}
+
+func rib(seq uint32, pfx string, addPath bool, es ...entry) []byte {
+	var b buf
+	b.u32(seq)
+	prefix(&b, pfx)
+	entries(&b, addPath, es...)
+	return b.Bytes()
+}
+
+func ribGeneric(seq uint32, afi uint16, safi uint8, pfx string, es ...entry) []byte {
+	var b buf
+	b.u32(seq)
+	b.u16(afi)
+	b.u8(safi)
+	if pfx == "" {
+		b.u8(0)
+	} else {
+		prefix(&b, pfx)
+	}
+	entries(&b, false, es...)
+	return b.Bytes()
+}
+
+func seq(asns ...uint32) segment { return segment{2, asns} }
+func set(asns ...uint32) segment { return segment{1, asns} }
+
+func main() {
+	var out bytes.Buffer
+	record(&out, 16, 4, []byte("not a table dump"))
+	record(&out, 13, 1, peerIndexTable(
+		peer{typ: 2, id: "192.0.2.1", addr: "192.0.2.1", as: 64500},
+		peer{typ: 3, id: "192.0.2.2", addr: "2001:db8::2", as: 4200000000},
+		peer{typ: 0, id: "192.0.2.3", addr: "192.0.2.3", as: 64501},
+	))
+	record(&out, 13, 2, rib(0, "10.0.0.0/8", false,
+		entry{peer: 0, attrs: [][]byte{origin(), asPath(0x40, seq(64500, 64496)), nextHop("192.0.2.1")}},
+		entry{peer: 2, attrs: [][]byte{origin(), asPath(0x40, seq(64501, 64497)), nextHop("192.0.2.3")}},
+	))
+	record(&out, 13, 2, rib(1, "198.51.100.0/24", false,
+		entry{peer: 0, attrs: [][]byte{asPath(0x40, seq(64500, 64510)), nextHop("192.0.2.1")}},
+	))
+	record(&out, 13, 2, rib(2, "198.51.100.128/25", false,
+		entry{peer: 0, attrs: [][]byte{asPath(0x40, seq(64500, 64511)), nextHop("192.0.2.1")}},
+	))
+	record(&out, 13, 2, rib(3, "203.0.113.0/24", false,
+		entry{peer: 0, attrs: [][]byte{asPath(0x40, seq(64500), set(64512, 64513)), nextHop("192.0.2.1")}},
+	))
+	record(&out, 13, 3, rib(4, "192.0.2.0/24", false,
+		entry{peer: 0, attrs: [][]byte{asPath(0x40, seq(64500, 64520)), nextHop("192.0.2.1")}},
+	))
+	record(&out, 13, 4, rib(5, "2001:db8::/32", false,
+		entry{peer: 1, attrs: [][]byte{origin(), asPath(0x50, seq(4200000000, 64496)), mpReach("2001:db8::2")}},
+	))
+	record(&out, 13, 6, ribGeneric(6, 2, 1, "2001:db8:8000::/33",
+		entry{peer: 1, attrs: [][]byte{asPath(0x40, segment{3, []uint32{65000, 65001}}, seq(4200000000, 64499)), mpReachFull("2001:db8::2", "fe80::2")}},
+	))
+	record(&out, 13, 6, ribGeneric(7, 25, 65, "",
+		entry{peer: 0, attrs: [][]byte{origin()}},
+	))
+	if err := os.WriteFile("rib.mrt", out.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+
+	out.Reset()
+	record(&out, 13, 1, peerIndexTable(
+		peer{typ: 2, id: "192.0.2.1", addr: "192.0.2.1", as: 64500},
+	))
+	record(&out, 13, 8, rib(0, "192.0.2.0/24", true,
+		entry{peer: 0, pathID: 1, attrs: [][]byte{asPath(0x40, seq(64500, 64496)), nextHop("192.0.2.1")}},
+		entry{peer: 0, pathID: 2, attrs: [][]byte{asPath(0x40, seq(64500, 64502, 64497)), nextHop("192.0.2.1")}},
+	))
+	record(&out, 13, 10, rib(1, "::/0", true,
+		entry{peer: 0, pathID: 7, attrs: [][]byte{asPath(0x40, seq(64500)), mpReach("2001:db8::1")}},
+	))
+	if err := os.WriteFile("addpath.mrt", out.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,235 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PortRange is an inclusive range of TCP or UDP ports.
+type PortRange struct {
+	From, To uint16
+}
+
+// Contains reports whether port lies within r.
+func (r PortRange) Contains(port uint16) bool {
+	return r.From <= port && port <= r.To
+}
+
+// Count returns the number of ports in r.
+func (r PortRange) Count() int {
+	return int(r.To) - int(r.From) + 1
+}
+
+// String returns r as a single port, e.g. 80, or as a range, e.g.
+// 8000-8080.
+func (r PortRange) String() string {
+	if r.From == r.To {
+		return strconv.Itoa(int(r.From))
+	}
+	return strconv.Itoa(int(r.From)) + "-" + strconv.Itoa(int(r.To))
+}
+
+// ParsePortRange parses a port number, e.g. 80, or an inclusive range
+// of port numbers, e.g. 8000-8100.
+func ParsePortRange(s string) (PortRange, error) {
+	from, to, isRange := strings.Cut(s, "-")
+	a, err := parsePort(from)
+	if err != nil {
+		return PortRange{}, err
+	}
+	if !isRange {
+		return PortRange{a, a}, nil
+	}
+	b, err := parsePort(to)
+	if err != nil {
+		return PortRange{}, err
+	}
+	if a > b {
+		return PortRange{}, fmt.Errorf("invalid port range %q", s)
+	}
+	return PortRange{a, b}, nil
+}
+
+// parsePort parses a decimal port number.
+func parsePort(s string) (uint16, error) {
+	n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return uint16(n), nil
+}
+
+// PortSet is an immutable set of ports. Like IPSet, it is kept as a
+// sorted list of non-overlapping, non-adjacent ranges, so two sets
+// holding the same ports are always Equal. The zero value is the empty
+// set.
+type PortSet struct {
+	ranges []PortRange
+}
+
+// NewPortSet returns the set of ports covered by ranges.
+func NewPortSet(ranges ...PortRange) (PortSet, error) {
+	for _, r := range ranges {
+		if r.From > r.To {
+			return PortSet{}, fmt.Errorf("invalid port range %d-%d", r.From, r.To)
+		}
+	}
+	return PortSet{mergePorts(slices.Clone(ranges))}, nil
+}
+
+// ParsePortSet parses a comma-separated list of ports, port ranges and
+// service names, e.g. "ssh,80,8000-8100". Service names are resolved
+// for network as LookupPort does.
+func ParsePortSet(s, network string) (PortSet, error) {
+	if strings.TrimSpace(s) == "" {
+		return PortSet{}, errors.New("empty port list")
+	}
+	var ranges []PortRange
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		r, err := ParsePortRange(item)
+		if err != nil {
+			port, lerr := LookupPort(network, item)
+			if lerr != nil {
+				return PortSet{}, err
+			}
+			r = PortRange{port, port}
+		}
+		ranges = append(ranges, r)
+	}
+	return PortSet{mergePorts(ranges)}, nil
+}
+
+// mergePorts sorts ranges and merges any that overlap or abut. The
+// slice is modified in place.
+func mergePorts(ranges []PortRange) []PortRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+	slices.SortFunc(ranges, func(a, b PortRange) int { return cmp.Compare(a.From, b.From) })
+	out := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &out[len(out)-1]
+		if int(r.From) <= int(last.To)+1 {
+			last.To = max(last.To, r.To)
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// CompactPorts returns ports, which may be unsorted and repeated, as a
+// comma-separated list in which consecutive ports are merged into
+// ranges, e.g. "22,80-82" for 80, 22, 81, 82.
+func CompactPorts(ports ...uint16) string {
+	ranges := make([]PortRange, len(ports))
+	for i, p := range ports {
+		ranges[i] = PortRange{p, p}
+	}
+	return PortSet{mergePorts(ranges)}.String()
+}
+
+// Ranges returns the ranges making up s.
+func (s PortSet) Ranges() []PortRange {
+	return slices.Clone(s.ranges)
+}
+
+// IsEmpty reports whether s holds no ports.
+func (s PortSet) IsEmpty() bool {
+	return len(s.ranges) == 0
+}
+
+// Equal reports whether s and o hold the same ports.
+func (s PortSet) Equal(o PortSet) bool {
+	return slices.Equal(s.ranges, o.ranges)
+}
+
+// Count returns the number of ports in s.
+func (s PortSet) Count() int {
+	n := 0
+	for _, r := range s.ranges {
+		n += r.Count()
+	}
+	return n
+}
+
+// Contains reports whether port is in s.
+func (s PortSet) Contains(port uint16) bool {
+	i, found := slices.BinarySearchFunc(s.ranges, port, func(r PortRange, port uint16) int {
+		return cmp.Compare(r.From, port)
+	})
+	if found {
+		return true
+	}
+	return i > 0 && s.ranges[i-1].Contains(port)
+}
+
+// ContainsSet reports whether every port in o is in s.
+func (s PortSet) ContainsSet(o PortSet) bool {
+	return o.Difference(s).IsEmpty()
+}
+
+// Overlaps reports whether s and o share a port.
+func (s PortSet) Overlaps(o PortSet) bool {
+	return !s.Intersect(o).IsEmpty()
+}
+
+// Union returns the set of ports in s or o.
+func (s PortSet) Union(o PortSet) PortSet {
+	return PortSet{mergePorts(slices.Concat(s.ranges, o.ranges))}
+}
+
+// Intersect returns the set of ports in both s and o.
+func (s PortSet) Intersect(o PortSet) PortSet {
+	var out []PortRange
+	for i, j := 0, 0; i < len(s.ranges) && j < len(o.ranges); {
+		a, b := s.ranges[i], o.ranges[j]
+		if lo, hi := max(a.From, b.From), min(a.To, b.To); lo <= hi {
+			out = append(out, PortRange{lo, hi})
+		}
+		if a.To < b.To {
+			i++
+		} else {
+			j++
+		}
+	}
+	return PortSet{out}
+}
+
+// Difference returns the set of ports in s but not in o.
+func (s PortSet) Difference(o PortSet) PortSet {
+	return s.Intersect(o.Complement())
+}
+
+// Complement returns the set of ports not in s.
+func (s PortSet) Complement() PortSet {
+	var out []PortRange
+	next := 0
+	for _, r := range s.ranges {
+		if int(r.From) > next {
+			out = append(out, PortRange{uint16(next), r.From - 1})
+		}
+		next = int(r.To) + 1
+	}
+	if next <= 65535 {
+		out = append(out, PortRange{uint16(next), 65535})
+	}
+	return PortSet{out}
+}
+
+// String returns s as a compact comma-separated list, e.g. "22,80-82".
+func (s PortSet) String() string {
+	xs := make([]string, len(s.ranges))
+	for i, r := range s.ranges {
+		xs[i] = r.String()
+	}
+	return strings.Join(xs, ",")
+}
@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import "testing"
+
+func TestParsePortRange(t *testing.T) {
+	testpairs := []struct {
+		input    string
+		expected PortRange
+	}{
+		{input: "80", expected: PortRange{80, 80}},
+		{input: "0", expected: PortRange{0, 0}},
+		{input: "8000-8100", expected: PortRange{8000, 8100}},
+		{input: "1-65535", expected: PortRange{1, 65535}},
+	}
+
+	for _, pair := range testpairs {
+		actual, err := ParsePortRange(pair.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != pair.expected {
+			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
+		}
+	}
+
+	for _, s := range []string{"", "http", "65536", "-1", "80-", "100-90", "1-2-3"} {
+		if _, err := ParsePortRange(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+func TestParsePortSet(t *testing.T) {
+	testpairs := []struct {
+		input    string
+		network  string
+		expected string
+		count    int
+	}{
+		{input: "80,81,82", expected: "80-82", count: 3},
+		{input: "443, 80, 8000-8100, 8050", expected: "80,443,8000-8100", count: 103},
+		{input: "ssh,http,https", network: "tcp", expected: "22,80,443", count: 3},
+		{input: "domain,ntp", network: "udp", expected: "53,123", count: 2},
+		{input: "0-65535", expected: "0-65535", count: 65536},
+	}
+
+	for _, pair := range testpairs {
+		s, err := ParsePortSet(pair.input, pair.network)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := s.String(); actual != pair.expected {
+			t.Errorf("%s: expected %s, got %s", pair.input, pair.expected, actual)
+		}
+		if actual := s.Count(); actual != pair.count {
+			t.Errorf("%s: expected %d ports, got %d", pair.input, pair.count, actual)
+		}
+	}
+
+	for _, s := range []string{"", "80,,81", "nosuchservice", "90-80", "ntp"} {
+		if _, err := ParsePortSet(s, "tcp"); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+func TestPortSetOperations(t *testing.T) {
+	mustPortSet := func(s string) PortSet {
+		t.Helper()
+		ps, err := ParsePortSet(s, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ps
+	}
+	a, b := mustPortSet("20-25,80,443"), mustPortSet("22,80-90,8080")
+	testpairs := []struct {
+		actual   string
+		expected string
+	}{
+		{actual: a.Union(b).String(), expected: "20-25,80-90,443,8080"},
+		{actual: a.Intersect(b).String(), expected: "22,80"},
+		{actual: a.Difference(b).String(), expected: "20-21,23-25,443"},
+		{actual: b.Difference(a).String(), expected: "81-90,8080"},
+		{actual: a.Complement().String(), expected: "0-19,26-79,81-442,444-65535"},
+		{actual: PortSet{}.Complement().String(), expected: "0-65535"},
+		{actual: PortSet{}.Complement().Complement().String(), expected: ""},
+	}
+
+	for _, pair := range testpairs {
+		if pair.actual != pair.expected {
+			t.Errorf("expected %s, got %s", pair.expected, pair.actual)
+		}
+	}
+
+	if !a.Overlaps(b) || a.Overlaps(mustPortSet("26-79")) {
+		t.Error("unexpected Overlaps result")
+	}
+	if !a.ContainsSet(mustPortSet("21-23,443")) || a.ContainsSet(b) {
+		t.Error("unexpected ContainsSet result")
+	}
+	if !a.Union(b).Equal(b.Union(a)) || a.Equal(b) {
+		t.Error("unexpected Equal result")
+	}
+	for _, port := range []uint16{20, 22, 25, 80, 443} {
+		if !a.Contains(port) {
+			t.Errorf("expected %d in %v", port, a)
+		}
+	}
+	for _, port := range []uint16{0, 19, 26, 79, 81, 444, 65535} {
+		if a.Contains(port) {
+			t.Errorf("expected %d not in %v", port, a)
+		}
+	}
+}
+
+func TestNewPortSet(t *testing.T) {
+	s, err := NewPortSet(PortRange{10, 20}, PortRange{21, 30}, PortRange{5, 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := s.String(); actual != "5-30" {
+		t.Errorf("expected 5-30, got %s", actual)
+	}
+	if _, err := NewPortSet(PortRange{20, 10}); err == nil {
+		t.Error("expected error")
+	}
+	if s, _ := NewPortSet(); !s.IsEmpty() {
+		t.Errorf("expected empty set, got %v", s)
+	}
+}
+
+func TestCompactPorts(t *testing.T) {
+	testpairs := []struct {
+		ports    []uint16
+		expected string
+	}{
+		{ports: []uint16{80, 81, 82}, expected: "80-82"},
+		{ports: []uint16{82, 22, 80, 81, 80}, expected: "22,80-82"},
+		{ports: []uint16{65535, 0, 65534}, expected: "0,65534-65535"},
+		{ports: nil, expected: ""},
+	}
+
+	for _, pair := range testpairs {
+		if actual := CompactPorts(pair.ports...); actual != pair.expected {
+			t.Errorf("%v: expected %s, got %s", pair.ports, pair.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+)
+
+// Nipsv4Prefix computes the number of IP addresses in the given IPv4
+// prefix. IPv4-mapped IPv6 prefixes are rejected; use UnmapPrefix to
+// convert them first.
+func Nipsv4Prefix(p netip.Prefix) (uint64, error) {
+	if !p.IsValid() {
+		return 0, errors.New("invalid netip.Prefix")
+	}
+	if !p.Addr().Is4() {
+		return 0, fmt.Errorf("%s is not an IPv4 prefix", p)
+	}
+	return 1 << (32 - p.Bits()), nil
+}
+
+// Nipsv6Prefix computes the number of IP addresses in the given IPv6
+// prefix.
+func Nipsv6Prefix(p netip.Prefix) (*big.Int, error) {
+	if !p.IsValid() {
+		return nil, errors.New("invalid netip.Prefix")
+	}
+	if !p.Addr().Is6() {
+		return nil, fmt.Errorf("%s is not an IPv6 prefix", p)
+	}
+	return new(big.Int).Lsh(big.NewInt(1), uint(128-p.Bits())), nil
+}
+
+// NipsPrefix computes the number of IP addresses in the given prefix,
+// which may be either IPv4 or IPv6.
+func NipsPrefix(p netip.Prefix) (*big.Int, error) {
+	if !p.IsValid() {
+		return nil, errors.New("invalid netip.Prefix")
+	}
+	return new(big.Int).Lsh(big.NewInt(1), uint(p.Addr().BitLen()-p.Bits())), nil
+}
+
+// UnmapPrefix converts an IPv4-mapped IPv6 prefix of length 96 or
+// more, e.g. ::ffff:10.0.0.0/104, into its IPv4 equivalent, e.g.
+// 10.0.0.0/8. Any other prefix is returned unchanged.
+func UnmapPrefix(p netip.Prefix) netip.Prefix {
+	if !p.IsValid() || !p.Addr().Is4In6() || p.Bits() < 96 {
+		return p
+	}
+	return netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+}
+
+// PrefixFromIPNet converts cidr into a netip.Prefix. Following the
+// conventions of package net, an IPv4 address paired with a 16-byte
+// mask of length 96 or more yields an IPv4 prefix. The host bits of
+// cidr.IP are preserved; call Masked on the result to clear them.
+func PrefixFromIPNet(cidr *net.IPNet) (netip.Prefix, error) {
+	if cidr == nil {
+		return netip.Prefix{}, errors.New("nil net.IPNet")
+	}
+	ones, bits, err := checkMask(cidr.Mask)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr, ok := netip.AddrFromSlice(cidr.IP)
+	if !ok {
+		return netip.Prefix{}, fmt.Errorf("%s is not a valid IP address", cidr.IP)
+	}
+	switch {
+	case bits == 8*net.IPv4len:
+		if !addr.Unmap().Is4() {
+			return netip.Prefix{}, fmt.Errorf("%s is not an IPv4 address", cidr.IP)
+		}
+		return netip.PrefixFrom(addr.Unmap(), ones), nil
+	case addr.Unmap().Is4() && ones >= 96:
+		return netip.PrefixFrom(addr.Unmap(), ones-96), nil
+	case addr.Is4():
+		return netip.Prefix{}, fmt.Errorf("%s is not a valid IPv4 mask", cidr.Mask)
+	default:
+		return netip.PrefixFrom(addr, ones), nil
+	}
+}
+
+// IPNetFromPrefix converts p into a *net.IPNet. IPv4 prefixes use
+// 4-byte addresses and masks, IPv6 prefixes use 16-byte ones. The
+// host bits of p are preserved.
+func IPNetFromPrefix(p netip.Prefix) (*net.IPNet, error) {
+	if !p.IsValid() {
+		return nil, errors.New("invalid netip.Prefix")
+	}
+	addr := p.Addr()
+	return &net.IPNet{
+		IP:   net.IP(addr.AsSlice()),
+		Mask: net.CIDRMask(p.Bits(), addr.BitLen()),
+	}, nil
+}
+
+// AddrFromIP converts ip into a netip.Addr. Since package net stores
+// IPv4 addresses in IPv4-mapped IPv6 form, the result is unmapped.
+func AddrFromIP(ip net.IP) (netip.Addr, error) {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return netip.Addr{}, fmt.Errorf("%s is not a valid IP address", ip)
+	}
+	return addr.Unmap(), nil
+}
+
+// AddrFromIPAddr converts ipa into a netip.Addr, carrying over its
+// zone. Zones are only meaningful for IPv6, so a zoned IPv4 address
+// is an error.
+func AddrFromIPAddr(ipa *net.IPAddr) (netip.Addr, error) {
+	if ipa == nil {
+		return netip.Addr{}, errors.New("nil net.IPAddr")
+	}
+	addr, err := AddrFromIP(ipa.IP)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if ipa.Zone == "" {
+		return addr, nil
+	}
+	if addr.Is4() {
+		return netip.Addr{}, fmt.Errorf("%s%%%s: IPv4 addresses cannot have a zone", addr, ipa.Zone)
+	}
+	return addr.WithZone(ipa.Zone), nil
+}
+
+// IPAddrFromAddr converts addr into a *net.IPAddr, carrying over its
+// zone.
+func IPAddrFromAddr(addr netip.Addr) (*net.IPAddr, error) {
+	if !addr.IsValid() {
+		return nil, errors.New("invalid netip.Addr")
+	}
+	return &net.IPAddr{IP: net.IP(addr.AsSlice()), Zone: addr.Zone()}, nil
+}
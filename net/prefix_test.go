@@ -0,0 +1,238 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestNipsv4Prefix(t *testing.T) {
+	for bits := 0; bits <= 32; bits++ {
+		p := netip.MustParsePrefix(fmt.Sprintf("%s/%d", ipv4addr, bits))
+		_, ipn, err := net.ParseCIDR(p.String())
+		if err != nil {
+			t.Fatal("failed to parse", p)
+		}
+		expected, err := Nipsv4(ipn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := Nipsv4Prefix(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Errorf("%s: expected %v, got %v", p, expected, actual)
+		}
+	}
+}
+
+func TestNipsv4PrefixWithIPv6(t *testing.T) {
+	inputs := []netip.Prefix{
+		{},
+		netip.MustParsePrefix(fmt.Sprintf("%s/64", ipv6addr)),
+		netip.MustParsePrefix("::ffff:10.0.0.0/104"),
+	}
+	for _, input := range inputs {
+		actual, err := Nipsv4Prefix(input)
+		if err == nil {
+			t.Errorf("%s: expected error, got %v", input, actual)
+		}
+	}
+}
+
+func TestNipsv6Prefix(t *testing.T) {
+	for bits := 0; bits <= 128; bits++ {
+		p := netip.MustParsePrefix(fmt.Sprintf("%s/%d", ipv6addr, bits))
+		_, ipn, err := net.ParseCIDR(p.String())
+		if err != nil {
+			t.Fatal("failed to parse", p)
+		}
+		expected, err := Nipsv6(ipn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := Nipsv6Prefix(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.Cmp(expected) != 0 {
+			t.Errorf("%s: expected %v, got %v", p, expected, actual)
+		}
+	}
+}
+
+func TestNipsv6PrefixWithIPv4(t *testing.T) {
+	inputs := []netip.Prefix{
+		{},
+		netip.MustParsePrefix(fmt.Sprintf("%s/24", ipv4addr)),
+	}
+	for _, input := range inputs {
+		actual, err := Nipsv6Prefix(input)
+		if err == nil {
+			t.Errorf("%s: expected error, got %v", input, actual)
+		}
+	}
+}
+
+func TestNipsPrefix(t *testing.T) {
+	testpairs := []struct {
+		input    string
+		expected string
+	}{
+		{input: "0.0.0.0/0", expected: "4294967296"},
+		{input: "10.0.0.0/8", expected: "16777216"},
+		{input: "::/0", expected: "340282366920938463463374607431768211456"},
+		{input: "::ffff:10.0.0.0/104", expected: "16777216"},
+		{input: "2001:db8::/32", expected: "79228162514264337593543950336"},
+	}
+
+	for _, pair := range testpairs {
+		actual, err := NipsPrefix(netip.MustParsePrefix(pair.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.String() != pair.expected {
+			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
+		}
+	}
+
+	if actual, err := NipsPrefix(netip.Prefix{}); err == nil {
+		t.Error("Expected error, got", actual)
+	}
+}
+
+func TestUnmapPrefix(t *testing.T) {
+	testpairs := []struct {
+		input    string
+		expected string
+	}{
+		{input: "::ffff:10.0.0.0/104", expected: "10.0.0.0/8"},
+		{input: "::ffff:1.2.3.4/128", expected: "1.2.3.4/32"},
+		{input: "::ffff:0.0.0.0/96", expected: "0.0.0.0/0"},
+		{input: "::ffff:0.0.0.0/95", expected: "::ffff:0.0.0.0/95"},
+		{input: "10.0.0.0/8", expected: "10.0.0.0/8"},
+		{input: "2001:db8::/32", expected: "2001:db8::/32"},
+	}
+
+	for _, pair := range testpairs {
+		actual := UnmapPrefix(netip.MustParsePrefix(pair.input))
+		if actual.String() != pair.expected {
+			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
+		}
+	}
+}
+
+func TestPrefixFromIPNet(t *testing.T) {
+	testpairs := []struct {
+		input    *net.IPNet
+		expected string
+	}{
+		{input: &net.IPNet{IP: net.ParseIP("10.1.2.3"), Mask: net.CIDRMask(8, 32)}, expected: "10.1.2.3/8"},
+		{input: &net.IPNet{IP: net.ParseIP("10.1.2.3").To4(), Mask: net.CIDRMask(8, 32)}, expected: "10.1.2.3/8"},
+		{input: &net.IPNet{IP: net.ParseIP("10.0.0.0"), Mask: net.CIDRMask(104, 128)}, expected: "10.0.0.0/8"},
+		{input: &net.IPNet{IP: net.ParseIP("10.0.0.0").To4(), Mask: net.CIDRMask(120, 128)}, expected: "10.0.0.0/24"},
+		{input: &net.IPNet{IP: net.ParseIP("::ffff:0:0"), Mask: net.CIDRMask(80, 128)}, expected: "::ffff:0.0.0.0/80"},
+		{input: &net.IPNet{IP: net.ParseIP("2001:db8::1"), Mask: net.CIDRMask(64, 128)}, expected: "2001:db8::1/64"},
+	}
+
+	for _, pair := range testpairs {
+		actual, err := PrefixFromIPNet(pair.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.String() != pair.expected {
+			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
+		}
+	}
+}
+
+func TestPrefixFromIPNetErrors(t *testing.T) {
+	inputs := []*net.IPNet{
+		nil,
+		{IP: net.ParseIP("10.0.0.0"), Mask: net.IPv4Mask(255, 0, 255, 0)},
+		{IP: net.ParseIP("2001:db8::"), Mask: net.CIDRMask(8, 32)},
+		{IP: net.ParseIP("10.0.0.0").To4(), Mask: net.CIDRMask(64, 128)},
+		{IP: net.IP{1, 2, 3}, Mask: net.CIDRMask(8, 32)},
+	}
+
+	for _, input := range inputs {
+		actual, err := PrefixFromIPNet(input)
+		if err == nil {
+			t.Errorf("%v: expected error, got %v", input, actual)
+		}
+	}
+}
+
+func TestIPNetFromPrefix(t *testing.T) {
+	for _, input := range []string{"10.0.0.0/8", "1.2.3.4/32", "2001:db8::/32", "::ffff:10.0.0.0/104"} {
+		p := netip.MustParsePrefix(input)
+		ipn, err := IPNetFromPrefix(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := PrefixFromIPNet(ipn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != UnmapPrefix(p) {
+			t.Errorf("%s: expected %v, got %v", input, UnmapPrefix(p), actual)
+		}
+	}
+
+	if actual, err := IPNetFromPrefix(netip.Prefix{}); err == nil {
+		t.Error("Expected error, got", actual)
+	}
+}
+
+func TestAddrFromIPAddr(t *testing.T) {
+	testpairs := []struct {
+		input    *net.IPAddr
+		expected string
+	}{
+		{input: &net.IPAddr{IP: net.ParseIP("1.2.3.4")}, expected: "1.2.3.4"},
+		{input: &net.IPAddr{IP: net.ParseIP("::ffff:1.2.3.4")}, expected: "1.2.3.4"},
+		{input: &net.IPAddr{IP: net.ParseIP("fe80::1"), Zone: "eth0"}, expected: "fe80::1%eth0"},
+		{input: &net.IPAddr{IP: net.ParseIP("2001:db8::1")}, expected: "2001:db8::1"},
+	}
+
+	for _, pair := range testpairs {
+		actual, err := AddrFromIPAddr(pair.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.String() != pair.expected {
+			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
+		}
+		ipa, err := IPAddrFromAddr(actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ipa.IP.Equal(pair.input.IP) || ipa.Zone != pair.input.Zone {
+			t.Errorf("%s: expected %v, got %v", actual, pair.input, ipa)
+		}
+	}
+}
+
+func TestAddrFromIPAddrErrors(t *testing.T) {
+	inputs := []*net.IPAddr{
+		nil,
+		{IP: net.IP{1, 2, 3}},
+		{IP: net.ParseIP("1.2.3.4"), Zone: "eth0"},
+	}
+
+	for _, input := range inputs {
+		actual, err := AddrFromIPAddr(input)
+		if err == nil {
+			t.Errorf("%v: expected error, got %v", input, actual)
+		}
+	}
+
+	if actual, err := IPAddrFromAddr(netip.Addr{}); err == nil {
+		t.Error("Expected error, got", actual)
+	}
+}
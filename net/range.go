@@ -0,0 +1,134 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/netip"
+)
+
+// IPRange is an inclusive range of IP addresses of a single family.
+// The zero value is an invalid range.
+type IPRange struct {
+	From netip.Addr
+	To   netip.Addr
+}
+
+// NewIPRange returns the range of addresses from from to to,
+// inclusive. Both addresses must belong to the same family, and from
+// must not be greater than to. Zones are discarded.
+func NewIPRange(from, to netip.Addr) (IPRange, error) {
+	if !from.IsValid() || !to.IsValid() {
+		return IPRange{}, errors.New("invalid netip.Addr")
+	}
+	from, to = from.WithZone(""), to.WithZone("")
+	if from.Is4() != to.Is4() {
+		return IPRange{}, fmt.Errorf("%s and %s are not of the same address family", from, to)
+	}
+	if to.Less(from) {
+		return IPRange{}, fmt.Errorf("%s is greater than %s", from, to)
+	}
+	return IPRange{From: from, To: to}, nil
+}
+
+// IPRangeFromPrefix returns the range of addresses covered by p, or
+// the zero IPRange if p is invalid.
+func IPRangeFromPrefix(p netip.Prefix) IPRange {
+	if !p.IsValid() {
+		return IPRange{}
+	}
+	return IPRange{From: firstAddr(p), To: lastAddr(p)}
+}
+
+// IsValid reports whether r is a well-formed range.
+func (r IPRange) IsValid() bool {
+	return r.From.IsValid() && r.To.IsValid() &&
+		r.From.Is4() == r.To.Is4() && !r.To.Less(r.From)
+}
+
+// Contains reports whether addr lies within r.
+func (r IPRange) Contains(addr netip.Addr) bool {
+	if !r.IsValid() || !addr.IsValid() {
+		return false
+	}
+	addr = addr.WithZone("")
+	return addr.Is4() == r.From.Is4() && !addr.Less(r.From) && !r.To.Less(addr)
+}
+
+// Overlaps reports whether r and o have any address in common.
+func (r IPRange) Overlaps(o IPRange) bool {
+	if !r.IsValid() || !o.IsValid() || r.From.Is4() != o.From.Is4() {
+		return false
+	}
+	return !r.To.Less(o.From) && !o.To.Less(r.From)
+}
+
+// Size returns the number of addresses in r, counted the same way as
+// Nipsv4 and Nipsv6.
+func (r IPRange) Size() *big.Int {
+	if !r.IsValid() {
+		return new(big.Int)
+	}
+	d, _ := u128(r.To).sub(u128(r.From))
+	n := d.big()
+	return n.Add(n, big.NewInt(1))
+}
+
+// Prefixes returns the minimal list of prefixes that exactly covers r,
+// in ascending order.
+func (r IPRange) Prefixes() []netip.Prefix {
+	if !r.IsValid() {
+		return nil
+	}
+	is4 := r.From.Is4()
+	width := r.From.BitLen()
+	cur, end := u128(r.From), u128(r.To)
+	var out []netip.Prefix
+	for {
+		// Take the largest block that starts at cur and ends at or
+		// before end. Only the whole of ::/0 overflows the span.
+		span, _ := end.sub(cur)
+		hostbits := width
+		if n, carry := span.add(uint128{0, 1}); !carry {
+			hostbits = n.bitLen() - 1
+		}
+		hostbits = min(hostbits, cur.trailingZeros())
+		out = append(out, netip.PrefixFrom(cur.addr(is4), width-hostbits))
+		last := cur.or(hostMask(hostbits))
+		if last == end {
+			return out
+		}
+		cur, _ = last.add(uint128{0, 1})
+	}
+}
+
+// RangeToPrefixes returns the minimal list of prefixes that exactly
+// covers the addresses from from to to, inclusive.
+func RangeToPrefixes(from, to netip.Addr) ([]netip.Prefix, error) {
+	r, err := NewIPRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return r.Prefixes(), nil
+}
+
+// PrefixesToRanges merges prefixes, which may overlap and may mix
+// address families, into the minimal list of ranges covering them.
+func PrefixesToRanges(prefixes ...netip.Prefix) ([]IPRange, error) {
+	s, err := NewIPSet(prefixes...)
+	if err != nil {
+		return nil, err
+	}
+	return s.Ranges(), nil
+}
+
+// String returns r in from-to form.
+func (r IPRange) String() string {
+	if !r.IsValid() {
+		return "invalid IPRange"
+	}
+	return r.From.String() + "-" + r.To.String()
+}
@@ -0,0 +1,193 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"math/big"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestNewIPRange(t *testing.T) {
+	r, err := NewIPRange(netip.MustParseAddr("10.0.0.5"), netip.MustParseAddr("10.0.1.20"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.String() != "10.0.0.5-10.0.1.20" {
+		t.Errorf("expected 10.0.0.5-10.0.1.20, got %v", r)
+	}
+	if r.Size().Int64() != 272 {
+		t.Errorf("%s: expected 272, got %v", r, r.Size())
+	}
+
+	r, err = NewIPRange(netip.MustParseAddr("fe80::1%eth0"), netip.MustParseAddr("fe80::2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.From.Zone() != "" {
+		t.Errorf("%s: expected zone to be discarded", r)
+	}
+}
+
+func TestNewIPRangeErrors(t *testing.T) {
+	testpairs := []struct {
+		from netip.Addr
+		to   netip.Addr
+	}{
+		{from: netip.Addr{}, to: netip.MustParseAddr("10.0.0.1")},
+		{from: netip.MustParseAddr("10.0.0.1"), to: netip.MustParseAddr("::1")},
+		{from: netip.MustParseAddr("10.0.0.2"), to: netip.MustParseAddr("10.0.0.1")},
+	}
+
+	for _, pair := range testpairs {
+		actual, err := NewIPRange(pair.from, pair.to)
+		if err == nil {
+			t.Errorf("%s-%s: expected error, got %v", pair.from, pair.to, actual)
+		}
+	}
+}
+
+func TestIPRangeFromPrefix(t *testing.T) {
+	for _, input := range []string{"10.1.2.3/8", "0.0.0.0/0", "2001:db8::/32", "::/0", "1.2.3.4/32"} {
+		p := netip.MustParsePrefix(input)
+		r := IPRangeFromPrefix(p)
+		expected, err := NipsPrefix(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Size().Cmp(expected) != 0 {
+			t.Errorf("%s: expected %v, got %v", input, expected, r.Size())
+		}
+		if r.From != p.Masked().Addr() {
+			t.Errorf("%s: expected %v, got %v", input, p.Masked().Addr(), r.From)
+		}
+	}
+
+	if r := IPRangeFromPrefix(netip.Prefix{}); r.IsValid() {
+		t.Errorf("expected invalid range, got %v", r)
+	}
+}
+
+func TestIPRangeContainsAndOverlaps(t *testing.T) {
+	r := IPRange{From: netip.MustParseAddr("10.0.0.5"), To: netip.MustParseAddr("10.0.1.20")}
+	for addr, expected := range map[string]bool{
+		"10.0.0.4":        false,
+		"10.0.0.5":        true,
+		"10.0.0.255":      true,
+		"10.0.1.20":       true,
+		"10.0.1.21":       false,
+		"::ffff:10.0.0.5": false,
+	} {
+		if actual := r.Contains(netip.MustParseAddr(addr)); actual != expected {
+			t.Errorf("%s contains %s: expected %v, got %v", r, addr, expected, actual)
+		}
+	}
+
+	o := IPRange{From: netip.MustParseAddr("10.0.1.20"), To: netip.MustParseAddr("10.0.2.0")}
+	if !r.Overlaps(o) || !o.Overlaps(r) {
+		t.Errorf("expected %s and %s to overlap", r, o)
+	}
+	o.From = netip.MustParseAddr("10.0.1.21")
+	if r.Overlaps(o) {
+		t.Errorf("expected %s and %s not to overlap", r, o)
+	}
+}
+
+func TestRangeToPrefixes(t *testing.T) {
+	testpairs := []struct {
+		from     string
+		to       string
+		expected []string
+	}{
+		{from: "10.0.0.5", to: "10.0.1.20", expected: []string{"10.0.0.5/32", "10.0.0.6/31", "10.0.0.8/29", "10.0.0.16/28", "10.0.0.32/27", "10.0.0.64/26", "10.0.0.128/25", "10.0.1.0/28", "10.0.1.16/30", "10.0.1.20/32"}},
+		{from: "10.0.0.0", to: "10.0.0.255", expected: []string{"10.0.0.0/24"}},
+		{from: "1.2.3.4", to: "1.2.3.4", expected: []string{"1.2.3.4/32"}},
+		{from: "0.0.0.0", to: "255.255.255.255", expected: []string{"0.0.0.0/0"}},
+		{from: "0.0.0.1", to: "255.255.255.255", expected: []string{"0.0.0.1/32", "0.0.0.2/31", "0.0.0.4/30", "0.0.0.8/29", "0.0.0.16/28", "0.0.0.32/27", "0.0.0.64/26", "0.0.0.128/25", "0.0.1.0/24", "0.0.2.0/23", "0.0.4.0/22", "0.0.8.0/21", "0.0.16.0/20", "0.0.32.0/19", "0.0.64.0/18", "0.0.128.0/17", "0.1.0.0/16", "0.2.0.0/15", "0.4.0.0/14", "0.8.0.0/13", "0.16.0.0/12", "0.32.0.0/11", "0.64.0.0/10", "0.128.0.0/9", "1.0.0.0/8", "2.0.0.0/7", "4.0.0.0/6", "8.0.0.0/5", "16.0.0.0/4", "32.0.0.0/3", "64.0.0.0/2", "128.0.0.0/1"}},
+		{from: "::", to: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", expected: []string{"::/0"}},
+		{from: "2001:db8::", to: "2001:db8::1:0", expected: []string{"2001:db8::/112", "2001:db8::1:0/128"}},
+		{from: "::", to: "7fff:ffff:ffff:ffff:ffff:ffff:ffff:fffe", expected: nil},
+	}
+
+	for _, pair := range testpairs {
+		from, to := netip.MustParseAddr(pair.from), netip.MustParseAddr(pair.to)
+		actual, err := RangeToPrefixes(from, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pair.expected != nil {
+			if len(actual) != len(pair.expected) {
+				t.Errorf("%s-%s: expected %v, got %v", from, to, pair.expected, actual)
+				continue
+			}
+			for i := range actual {
+				if actual[i].String() != pair.expected[i] {
+					t.Errorf("%s-%s: expected %v, got %v", from, to, pair.expected, actual)
+					break
+				}
+			}
+		}
+
+		// The prefixes must be contiguous and their counts must add up
+		// to the size of the range.
+		total := new(big.Int)
+		next := from
+		for _, p := range actual {
+			if p.Addr() != next || p.Masked() != p {
+				t.Errorf("%s-%s: %s is not contiguous", from, to, p)
+			}
+			n, err := NipsPrefix(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			total.Add(total, n)
+			next = lastAddr(p).Next()
+		}
+		r := IPRange{From: from, To: to}
+		if total.Cmp(r.Size()) != 0 {
+			t.Errorf("%s-%s: expected %v addresses, got %v", from, to, r.Size(), total)
+		}
+	}
+
+	if _, err := RangeToPrefixes(netip.MustParseAddr("10.0.0.2"), netip.MustParseAddr("10.0.0.1")); err == nil {
+		t.Error("expected error for reversed range")
+	}
+}
+
+func TestPrefixesToRanges(t *testing.T) {
+	var prefixes []netip.Prefix
+	for _, s := range []string{"10.0.1.0/24", "2001:db8:1::/48", "10.0.0.0/24", "10.0.0.128/25", "10.0.3.0/24", "2001:db8::/48"} {
+		prefixes = append(prefixes, netip.MustParsePrefix(s))
+	}
+	actual, err := PrefixesToRanges(prefixes...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"10.0.0.0-10.0.1.255", "10.0.3.0-10.0.3.255", "2001:db8::-2001:db8:1:ffff:ffff:ffff:ffff:ffff"}
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	for i := range actual {
+		if actual[i].String() != expected[i] {
+			t.Errorf("expected %v, got %v", expected, actual)
+			break
+		}
+	}
+
+	// Converting back must produce the minimal prefix list.
+	var back []string
+	for _, r := range actual {
+		for _, p := range r.Prefixes() {
+			back = append(back, p.String())
+		}
+	}
+	if expected := []string{"10.0.0.0/23", "10.0.3.0/24", "2001:db8::/47"}; strings.Join(back, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected %v, got %v", expected, back)
+	}
+
+	if _, err := PrefixesToRanges(netip.Prefix{}); err == nil {
+		t.Error("expected error for invalid prefix")
+	}
+}
@@ -0,0 +1,241 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+const (
+	inAddrArpa = "in-addr.arpa."
+	ip6Arpa    = "ip6.arpa."
+	hexDigits  = "0123456789abcdef"
+)
+
+// ReverseName returns the fully qualified reverse-DNS name of addr,
+// e.g. 4.3.2.1.in-addr.arpa. for 1.2.3.4.
+func ReverseName(addr netip.Addr) (string, error) {
+	if !addr.IsValid() {
+		return "", errors.New("invalid netip.Addr")
+	}
+	return reverseName(addr, addr.BitLen()), nil
+}
+
+// reverseName returns the reverse-DNS name covering the first bits
+// bits of addr, which must fall on an octet (IPv4) or nibble (IPv6)
+// boundary.
+func reverseName(addr netip.Addr, bits int) string {
+	var sb strings.Builder
+	if addr.Is4() {
+		b := addr.As4()
+		for i := bits/8 - 1; i >= 0; i-- {
+			sb.WriteString(strconv.Itoa(int(b[i])))
+			sb.WriteByte('.')
+		}
+		sb.WriteString(inAddrArpa)
+		return sb.String()
+	}
+	b := addr.As16()
+	for i := bits/4 - 1; i >= 0; i-- {
+		nibble := b[i/2] >> 4
+		if i%2 == 1 {
+			nibble = b[i/2] & 0xf
+		}
+		sb.WriteByte(hexDigits[nibble])
+		sb.WriteByte('.')
+	}
+	sb.WriteString(ip6Arpa)
+	return sb.String()
+}
+
+// ReverseZones returns the reverse-DNS zones that exactly cover p.
+//
+// IPv4 prefixes of length 24 or less are expanded to the octet
+// boundaries at or below them, so a /22 yields four /24 zones. Longer
+// IPv4 prefixes are delegated classlessly as described in RFC 2317,
+// e.g. 0/26.2.0.192.in-addr.arpa. for 192.0.2.0/26.
+//
+// IPv6 prefixes are expanded to the nibble boundaries at or below
+// them, so a /46 yields four /48 zones.
+func ReverseZones(p netip.Prefix) ([]string, error) {
+	if !p.IsValid() {
+		return nil, errors.New("invalid netip.Prefix")
+	}
+	p = p.Masked()
+	addr := p.Addr()
+
+	if addr.Is4() && p.Bits() > 24 {
+		b := addr.As4()
+		return []string{fmt.Sprintf("%d/%d.%s", b[3], p.Bits(), reverseName(addr, 24))}, nil
+	}
+
+	step := 4
+	if addr.Is4() {
+		step = 8
+	}
+	boundary := (p.Bits() + step - 1) / step * step
+	subnets, err := Subnets(p, boundary)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(subnets))
+	for i, s := range subnets {
+		out[i] = reverseName(s.Addr(), boundary)
+	}
+	return out, nil
+}
+
+// ParseReverseName parses a reverse-DNS name under in-addr.arpa or
+// ip6.arpa, with or without the trailing dot, into the prefix it
+// denotes. A name holding a complete address yields a /32 or /128
+// prefix. RFC 2317 classless delegation names, such as
+// 0/26.2.0.192.in-addr.arpa or 0-63.2.0.192.in-addr.arpa, are also
+// accepted.
+func ParseReverseName(name string) (netip.Prefix, error) {
+	fqdn := strings.ToLower(name)
+	if !strings.HasSuffix(fqdn, ".") {
+		fqdn += "."
+	}
+	switch {
+	case fqdn == inAddrArpa:
+		return netip.PrefixFrom(netip.IPv4Unspecified(), 0), nil
+	case fqdn == ip6Arpa:
+		return netip.PrefixFrom(netip.IPv6Unspecified(), 0), nil
+	case strings.HasSuffix(fqdn, "."+inAddrArpa):
+		p, err := parseInAddrArpa(strings.Split(strings.TrimSuffix(fqdn, "."+inAddrArpa), "."))
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("%s: %w", name, err)
+		}
+		return p, nil
+	case strings.HasSuffix(fqdn, "."+ip6Arpa):
+		p, err := parseIP6Arpa(strings.Split(strings.TrimSuffix(fqdn, "."+ip6Arpa), "."))
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("%s: %w", name, err)
+		}
+		return p, nil
+	default:
+		return netip.Prefix{}, fmt.Errorf("%s is not under %s or %s", name, inAddrArpa, ip6Arpa)
+	}
+}
+
+// ParseReverseAddr parses a reverse-DNS name holding a complete
+// address.
+func ParseReverseAddr(name string) (netip.Addr, error) {
+	p, err := ParseReverseName(name)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if !p.IsSingleIP() {
+		return netip.Addr{}, fmt.Errorf("%s does not name a single address", name)
+	}
+	return p.Addr(), nil
+}
+
+// parseInAddrArpa parses the labels preceding in-addr.arpa, least
+// significant first.
+func parseInAddrArpa(labels []string) (netip.Prefix, error) {
+	if len(labels) > 4 {
+		return netip.Prefix{}, errors.New("too many labels")
+	}
+	var b [4]byte
+	n := len(labels)
+	for i, label := range labels {
+		if i == 0 && n == 4 {
+			if p, ok, err := parseClassless(label, labels[1:]); ok {
+				return p, err
+			}
+		}
+		v, err := parseOctet(label)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		b[n-1-i] = v
+	}
+	return netip.PrefixFrom(netip.AddrFrom4(b), 8*n), nil
+}
+
+// parseClassless parses an RFC 2317 label of the form start/bits or
+// start-end, reporting whether label has that form at all. parents
+// holds the three labels that follow it.
+func parseClassless(label string, parents []string) (netip.Prefix, bool, error) {
+	var (
+		start, last string
+		bits        int
+		err         error
+	)
+	if s, b, ok := strings.Cut(label, "/"); ok {
+		start = s
+		if bits, err = strconv.Atoi(b); err != nil || bits < 24 || bits > 32 {
+			return netip.Prefix{}, true, fmt.Errorf("invalid classless prefix length %q", b)
+		}
+	} else if s, e, ok := strings.Cut(label, "-"); ok {
+		start, last = s, e
+	} else {
+		return netip.Prefix{}, false, nil
+	}
+
+	var b [4]byte
+	for i, parent := range parents {
+		v, err := parseOctet(parent)
+		if err != nil {
+			return netip.Prefix{}, true, err
+		}
+		b[2-i] = v
+	}
+	if b[3], err = parseOctet(start); err != nil {
+		return netip.Prefix{}, true, err
+	}
+	if last != "" {
+		end, err := parseOctet(last)
+		if err != nil {
+			return netip.Prefix{}, true, err
+		}
+		ps, err := RangeToPrefixes(netip.AddrFrom4(b), netip.AddrFrom4([4]byte{b[0], b[1], b[2], end}))
+		if err != nil || len(ps) != 1 {
+			return netip.Prefix{}, true, fmt.Errorf("%s is not a CIDR block", label)
+		}
+		return ps[0], true, nil
+	}
+	p := netip.PrefixFrom(netip.AddrFrom4(b), bits)
+	if p.Masked() != p {
+		return netip.Prefix{}, true, fmt.Errorf("%s is not a CIDR block", label)
+	}
+	return p, true, nil
+}
+
+// parseOctet parses a decimal octet without leading zeros.
+func parseOctet(s string) (byte, error) {
+	v, err := strconv.ParseUint(s, 10, 8)
+	if err != nil || (len(s) > 1 && s[0] == '0') {
+		return 0, fmt.Errorf("invalid octet %q", s)
+	}
+	return byte(v), nil
+}
+
+// parseIP6Arpa parses the nibble labels preceding ip6.arpa, least
+// significant first.
+func parseIP6Arpa(labels []string) (netip.Prefix, error) {
+	if len(labels) > 32 {
+		return netip.Prefix{}, errors.New("too many labels")
+	}
+	var b [16]byte
+	n := len(labels)
+	for i, label := range labels {
+		if len(label) != 1 || strings.IndexByte(hexDigits, label[0]) < 0 {
+			return netip.Prefix{}, fmt.Errorf("invalid nibble %q", label)
+		}
+		j := n - 1 - i
+		nibble := byte(strings.IndexByte(hexDigits, label[0]))
+		if j%2 == 0 {
+			b[j/2] |= nibble << 4
+		} else {
+			b[j/2] |= nibble
+		}
+	}
+	return netip.PrefixFrom(netip.AddrFrom16(b), 4*n), nil
+}
@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestReverseName(t *testing.T) {
+	testpairs := []struct {
+		input    string
+		expected string
+	}{
+		{input: "1.2.3.4", expected: "4.3.2.1.in-addr.arpa."},
+		{input: "10.0.0.255", expected: "255.0.0.10.in-addr.arpa."},
+		{input: "2001:db8::567:89ab", expected: "b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."},
+		{input: "fe80::1%eth0", expected: "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.e.f.ip6.arpa."},
+	}
+
+	for _, pair := range testpairs {
+		actual, err := ReverseName(netip.MustParseAddr(pair.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != pair.expected {
+			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
+		}
+		addr, err := ParseReverseAddr(actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if addr != netip.MustParseAddr(pair.input).WithZone("") {
+			t.Errorf("%s: expected %v, got %v", actual, pair.input, addr)
+		}
+	}
+
+	if _, err := ReverseName(netip.Addr{}); err == nil {
+		t.Error("expected error for invalid address")
+	}
+}
+
+func TestReverseZones(t *testing.T) {
+	testpairs := []struct {
+		input    string
+		expected string
+	}{
+		{input: "10.0.0.0/8", expected: "10.in-addr.arpa."},
+		{input: "172.16.0.0/12", expected: "16.172.in-addr.arpa. 17.172.in-addr.arpa. 18.172.in-addr.arpa. 19.172.in-addr.arpa. 20.172.in-addr.arpa. 21.172.in-addr.arpa. 22.172.in-addr.arpa. 23.172.in-addr.arpa. 24.172.in-addr.arpa. 25.172.in-addr.arpa. 26.172.in-addr.arpa. 27.172.in-addr.arpa. 28.172.in-addr.arpa. 29.172.in-addr.arpa. 30.172.in-addr.arpa. 31.172.in-addr.arpa."},
+		{input: "192.0.2.0/23", expected: "2.0.192.in-addr.arpa. 3.0.192.in-addr.arpa."},
+		{input: "192.0.2.0/24", expected: "2.0.192.in-addr.arpa."},
+		{input: "192.0.2.64/26", expected: "64/26.2.0.192.in-addr.arpa."},
+		{input: "192.0.2.77/32", expected: "77/32.2.0.192.in-addr.arpa."},
+		{input: "0.0.0.0/0", expected: "in-addr.arpa."},
+		{input: "2001:db8::/32", expected: "8.b.d.0.1.0.0.2.ip6.arpa."},
+		{input: "2001:db8::/46", expected: "0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa. 1.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa. 2.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa. 3.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."},
+		{input: "::/0", expected: "ip6.arpa."},
+	}
+
+	for _, pair := range testpairs {
+		actual, err := ReverseZones(netip.MustParsePrefix(pair.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(actual, " ") != pair.expected {
+			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
+		}
+	}
+
+	if _, err := ReverseZones(netip.Prefix{}); err == nil {
+		t.Error("expected error for invalid prefix")
+	}
+}
+
+func TestParseReverseName(t *testing.T) {
+	testpairs := []struct {
+		input    string
+		expected string
+	}{
+		{input: "in-addr.arpa", expected: "0.0.0.0/0"},
+		{input: "10.in-addr.arpa.", expected: "10.0.0.0/8"},
+		{input: "2.0.192.IN-ADDR.ARPA", expected: "192.0.2.0/24"},
+		{input: "4.3.2.1.in-addr.arpa.", expected: "1.2.3.4/32"},
+		{input: "64/26.2.0.192.in-addr.arpa.", expected: "192.0.2.64/26"},
+		{input: "128-255.2.0.192.in-addr.arpa", expected: "192.0.2.128/25"},
+		{input: "ip6.arpa.", expected: "::/0"},
+		{input: "8.b.d.0.1.0.0.2.ip6.arpa.", expected: "2001:db8::/32"},
+		{input: "1.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa", expected: "2001:db8:1::/48"},
+	}
+
+	for _, pair := range testpairs {
+		actual, err := ParseReverseName(pair.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.String() != pair.expected {
+			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
+		}
+	}
+}
+
+func TestParseReverseNameErrors(t *testing.T) {
+	inputs := []string{
+		"example.com.",
+		"5.4.3.2.1.in-addr.arpa.",
+		"256.in-addr.arpa.",
+		"01.in-addr.arpa.",
+		"x.in-addr.arpa.",
+		"..in-addr.arpa.",
+		"64/23.2.0.192.in-addr.arpa.",
+		"65/26.2.0.192.in-addr.arpa.",
+		"0-99.2.0.192.in-addr.arpa.",
+		"g.ip6.arpa.",
+		"10.ip6.arpa.",
+		"0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.ip6.arpa.",
+	}
+
+	for _, input := range inputs {
+		actual, err := ParseReverseName(input)
+		if err == nil {
+			t.Errorf("%s: expected error, got %v", input, actual)
+		}
+	}
+
+	if actual, err := ParseReverseAddr("2.0.192.in-addr.arpa."); err == nil {
+		t.Errorf("expected error, got %v", actual)
+	}
+}
@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand/v2"
+	"net/netip"
+	"slices"
+)
+
+// Sampler draws uniformly random addresses and subnets from prefixes.
+// Its randomness comes from a caller-supplied source, so a seeded
+// source yields reproducible samples. Like rand.Rand, a Sampler is not
+// safe for concurrent use.
+type Sampler struct {
+	r *rand.Rand
+}
+
+// NewSampler returns a Sampler drawing from src.
+func NewSampler(src rand.Source) *Sampler {
+	return &Sampler{r: rand.New(src)}
+}
+
+// Addr returns an address of p chosen uniformly at random.
+func (s *Sampler) Addr(p netip.Prefix) (netip.Addr, error) {
+	addrs, err := s.Addrs(p, 1)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return addrs[0], nil
+}
+
+// Host returns a usable host address of p chosen uniformly at random.
+// The usable range, which excludes the IPv4 network and broadcast
+// addresses, is that reported by NewSubnetInfo.
+func (s *Sampler) Host(p netip.Prefix) (netip.Addr, error) {
+	hosts, err := s.Hosts(p, 1)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return hosts[0], nil
+}
+
+// Addrs returns n distinct addresses of p chosen uniformly at random,
+// that is, sampled without replacement, in ascending order.
+func (s *Sampler) Addrs(p netip.Prefix, n int) ([]netip.Addr, error) {
+	if !p.IsValid() {
+		return nil, errors.New("invalid netip.Prefix")
+	}
+	p = p.Masked()
+	return s.addrs(firstAddr(p), lastAddr(p), n)
+}
+
+// Hosts returns n distinct usable host addresses of p chosen uniformly
+// at random, in ascending order.
+func (s *Sampler) Hosts(p netip.Prefix, n int) ([]netip.Addr, error) {
+	si, err := NewSubnetInfo(p)
+	if err != nil {
+		return nil, err
+	}
+	return s.addrs(si.First, si.Last, n)
+}
+
+// addrs samples n distinct addresses from first to last inclusive.
+func (s *Sampler) addrs(first, last netip.Addr, n int) ([]netip.Addr, error) {
+	base := u128(first)
+	span, _ := u128(last).sub(base)
+	offsets, err := s.sample(span, n)
+	if err != nil {
+		return nil, fmt.Errorf("%s-%s: %w", first, last, err)
+	}
+	out := make([]netip.Addr, len(offsets))
+	for i, off := range offsets {
+		u, _ := base.add(off)
+		out[i] = u.addr(first.Is4())
+	}
+	return out, nil
+}
+
+// Subnets returns n distinct subnets of length bits within p chosen
+// uniformly at random, in ascending order. Being of equal length and
+// distinct, the subnets never overlap.
+func (s *Sampler) Subnets(p netip.Prefix, bits, n int) ([]netip.Prefix, error) {
+	if !p.IsValid() {
+		return nil, errors.New("invalid netip.Prefix")
+	}
+	if bits < p.Bits() || bits > p.Addr().BitLen() {
+		return nil, fmt.Errorf("cannot divide %s into /%d subnets", p, bits)
+	}
+	p = p.Masked()
+	indexes, err := s.sample(hostMask(bits-p.Bits()), n)
+	if err != nil {
+		return nil, fmt.Errorf("%s /%d: %w", p, bits, err)
+	}
+	base, shift := u128(p.Addr()), uint(p.Addr().BitLen()-bits)
+	out := make([]netip.Prefix, len(indexes))
+	for i, idx := range indexes {
+		u, _ := base.add(idx.lsh(shift))
+		out[i] = netip.PrefixFrom(u.addr(p.Addr().Is4()), bits)
+	}
+	return out, nil
+}
+
+// sample returns n distinct integers from 0 to limit inclusive, chosen
+// uniformly at random, in ascending order. It uses Floyd's algorithm,
+// so the cost depends on n alone, however large limit is.
+func (s *Sampler) sample(limit uint128, n int) ([]uint128, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("cannot sample %d items", n)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	start, borrow := limit.sub(uint128{0, uint64(n - 1)})
+	if borrow {
+		return nil, fmt.Errorf("cannot sample %d items from %s", n, new(big.Int).Add(limit.big(), big.NewInt(1)))
+	}
+	chosen := make(map[uint128]bool, n)
+	out := make([]uint128, 0, n)
+	for j := start; ; j, _ = j.add(uint128{0, 1}) {
+		t := s.uniform(j)
+		if chosen[t] {
+			t = j
+		}
+		chosen[t] = true
+		out = append(out, t)
+		if j == limit {
+			break
+		}
+	}
+	slices.SortFunc(out, uint128.cmp)
+	return out, nil
+}
+
+// uniform returns an integer from 0 to limit inclusive, chosen uniformly
+// at random by rejection sampling.
+func (s *Sampler) uniform(limit uint128) uint128 {
+	mask := hostMask(limit.bitLen())
+	for {
+		u := uint128{s.r.Uint64(), s.r.Uint64()}.and(mask)
+		if u.cmp(limit) <= 0 {
+			return u
+		}
+	}
+}
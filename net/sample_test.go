@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"math/rand/v2"
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestSamplerReproducible(t *testing.T) {
+	p := netip.MustParsePrefix("2001:db8::/32")
+	a, err := NewSampler(rand.NewPCG(1, 2)).Addrs(p, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewSampler(rand.NewPCG(1, 2)).Addrs(p, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(a, b) {
+		t.Errorf("expected equal samples, got %v and %v", a, b)
+	}
+}
+
+func TestSamplerAddrs(t *testing.T) {
+	s := NewSampler(rand.NewPCG(1, 2))
+	testpairs := []struct {
+		prefix string
+		n      int
+	}{
+		{prefix: "192.0.2.0/24", n: 100},
+		{prefix: "192.0.2.0/28", n: 16},
+		{prefix: "192.0.2.1/32", n: 1},
+		{prefix: "0.0.0.0/0", n: 50},
+		{prefix: "2001:db8::/64", n: 100},
+		{prefix: "::/0", n: 100},
+	}
+
+	for _, pair := range testpairs {
+		p := netip.MustParsePrefix(pair.prefix)
+		actual, err := s.Addrs(p, pair.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(actual) != pair.n {
+			t.Fatalf("%s: expected %d addresses, got %d", p, pair.n, len(actual))
+		}
+		for i, a := range actual {
+			if !p.Contains(a) {
+				t.Errorf("%s: %v is outside the prefix", p, a)
+			}
+			if i > 0 && !actual[i-1].Less(a) {
+				t.Errorf("%s: %v and %v are not distinct and ascending", p, actual[i-1], a)
+			}
+		}
+	}
+
+	if actual, err := s.Addrs(netip.MustParsePrefix("192.0.2.0/28"), 17); err == nil {
+		t.Errorf("expected error, got %v", actual)
+	}
+	if actual, err := s.Addrs(netip.MustParsePrefix("192.0.2.0/28"), -1); err == nil {
+		t.Errorf("expected error, got %v", actual)
+	}
+	if actual, err := s.Addrs(netip.MustParsePrefix("192.0.2.0/28"), 0); err != nil || len(actual) != 0 {
+		t.Errorf("expected no addresses, got %v (%v)", actual, err)
+	}
+	if actual, err := s.Addr(netip.Prefix{}); err == nil {
+		t.Errorf("expected error, got %v", actual)
+	}
+}
+
+func TestSamplerHosts(t *testing.T) {
+	s := NewSampler(rand.NewPCG(3, 4))
+	actual, err := s.Hosts(netip.MustParsePrefix("192.0.2.0/29"), 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []netip.Addr{
+		netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("192.0.2.2"), netip.MustParseAddr("192.0.2.3"),
+		netip.MustParseAddr("192.0.2.4"), netip.MustParseAddr("192.0.2.5"), netip.MustParseAddr("192.0.2.6"),
+	}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if actual, err := s.Hosts(netip.MustParsePrefix("192.0.2.0/29"), 7); err == nil {
+		t.Errorf("expected error, got %v", actual)
+	}
+
+	p := netip.MustParsePrefix("192.0.2.0/30")
+	for range 100 {
+		h, err := s.Host(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h == firstAddr(p) || h == lastAddr(p) {
+			t.Fatalf("%s: sampled reserved address %v", p, h)
+		}
+	}
+}
+
+func TestSamplerUniform(t *testing.T) {
+	s := NewSampler(rand.NewPCG(5, 6))
+	p := netip.MustParsePrefix("2001:db8::/125")
+	const draws = 8000
+	counts := map[netip.Addr]int{}
+	for range draws {
+		a, err := s.Addr(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[a]++
+	}
+	if len(counts) != 8 {
+		t.Fatalf("expected 8 distinct addresses, got %d", len(counts))
+	}
+	for a, n := range counts {
+		// Each count has a standard deviation of about 30.
+		if n < draws/8-150 || n > draws/8+150 {
+			t.Errorf("%v: drawn %d times out of %d", a, n, draws)
+		}
+	}
+}
+
+func TestSamplerSubnets(t *testing.T) {
+	s := NewSampler(rand.NewPCG(7, 8))
+	testpairs := []struct {
+		prefix string
+		bits   int
+		n      int
+	}{
+		{prefix: "10.0.0.0/8", bits: 24, n: 100},
+		{prefix: "192.0.2.0/24", bits: 26, n: 4},
+		{prefix: "2001:db8::/32", bits: 64, n: 100},
+		{prefix: "::/0", bits: 128, n: 10},
+	}
+
+	for _, pair := range testpairs {
+		p := netip.MustParsePrefix(pair.prefix)
+		actual, err := s.Subnets(p, pair.bits, pair.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(actual) != pair.n {
+			t.Fatalf("%s: expected %d subnets, got %d", p, pair.n, len(actual))
+		}
+		for i, q := range actual {
+			if q.Bits() != pair.bits || q.Masked() != q || !p.Overlaps(q) {
+				t.Errorf("%s: unexpected subnet %v", p, q)
+			}
+			if i > 0 && actual[i-1].Overlaps(q) {
+				t.Errorf("%s: %v overlaps %v", p, actual[i-1], q)
+			}
+		}
+	}
+
+	for _, bits := range []int{7, 33} {
+		if actual, err := s.Subnets(netip.MustParsePrefix("10.0.0.0/8"), bits, 1); err == nil {
+			t.Errorf("/%d: expected error, got %v", bits, actual)
+		}
+	}
+	if actual, err := s.Subnets(netip.MustParsePrefix("192.0.2.0/24"), 26, 5); err == nil {
+		t.Errorf("expected error, got %v", actual)
+	}
+}
@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"bufio"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// specialCSV is the embedded copy of the IANA special-purpose address
+// registries. Each entry carries the registry attributes defined by
+// RFC 6890 as updated by RFC 8190.
+//
+//go:embed special.csv
+var specialCSV string
+
+// Registry names used in SpecialPurpose.Registry.
+const (
+	RegistryIPv4Special   = "ipv4-special"
+	RegistryIPv6Special   = "ipv6-special"
+	RegistryIPv4Multicast = "ipv4-multicast"
+	RegistryIPv6Multicast = "ipv6-multicast"
+)
+
+// SpecialPurpose is an entry in an IANA special-purpose address
+// registry.
+type SpecialPurpose struct {
+	Registry           string       // the registry holding the entry
+	Prefix             netip.Prefix // the address block
+	Name               string       // the name of the block
+	RFC                string       // the defining document
+	Source             bool         // valid as a source address
+	Destination        bool         // valid as a destination address
+	Forwardable        bool         // may be forwarded by routers
+	GloballyReachable  bool         // reachable beyond an administrative domain
+	ReservedByProtocol bool         // reserved by the protocol specification
+}
+
+// special holds the parsed registry.
+var special = sync.OnceValues(func() (*Table[[]SpecialPurpose], error) {
+	return parseSpecial(specialCSV)
+})
+
+// parseSpecial parses the embedded registry into a Table. Several
+// registries may list the same block, so each prefix maps to a list.
+func parseSpecial(data string) (*Table[[]SpecialPurpose], error) {
+	r := csv.NewReader(strings.NewReader(data))
+	r.Comment = '#'
+	r.FieldsPerRecord = 9
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var tbl Table[[]SpecialPurpose]
+	for _, rec := range records {
+		p, err := netip.ParsePrefix(rec[1])
+		if err != nil {
+			return nil, err
+		}
+		sp := SpecialPurpose{Registry: rec[0], Prefix: p, Name: rec[2], RFC: rec[3]}
+		for i, b := range []*bool{&sp.Source, &sp.Destination, &sp.Forwardable, &sp.GloballyReachable, &sp.ReservedByProtocol} {
+			if *b, err = strconv.ParseBool(rec[4+i]); err != nil {
+				return nil, fmt.Errorf("%s: %w", p, err)
+			}
+		}
+		list, _ := tbl.Get(p)
+		if err := tbl.Insert(p, append(list, sp)); err != nil {
+			return nil, err
+		}
+	}
+	return &tbl, nil
+}
+
+// SpecialRegistryVersion returns the version of the embedded
+// special-purpose address registry.
+func SpecialRegistryVersion() string {
+	sc := bufio.NewScanner(strings.NewReader(specialCSV))
+	for sc.Scan() {
+		if v, ok := strings.CutPrefix(sc.Text(), "# version:"); ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// SpecialPurposes returns every entry in the embedded registry, in
+// ascending order of prefix.
+func SpecialPurposes() ([]SpecialPurpose, error) {
+	tbl, err := special()
+	if err != nil {
+		return nil, err
+	}
+	var out []SpecialPurpose
+	tbl.Walk(func(_ netip.Prefix, list []SpecialPurpose) bool {
+		out = append(out, list...)
+		return true
+	})
+	return out, nil
+}
+
+// Classify returns the registry entries whose blocks contain addr,
+// from the least to the most specific. A nil result means addr is
+// ordinary global unicast space.
+func Classify(addr netip.Addr) ([]SpecialPurpose, error) {
+	if !addr.IsValid() {
+		return nil, errors.New("invalid netip.Addr")
+	}
+	addr = addr.WithZone("")
+	return ClassifyPrefix(netip.PrefixFrom(addr, addr.BitLen()))
+}
+
+// ClassifyPrefix returns the registry entries whose blocks contain
+// every address in p, from the least to the most specific.
+func ClassifyPrefix(p netip.Prefix) ([]SpecialPurpose, error) {
+	if !p.IsValid() {
+		return nil, errors.New("invalid netip.Prefix")
+	}
+	tbl, err := special()
+	if err != nil {
+		return nil, err
+	}
+	var out []SpecialPurpose
+	tbl.WalkCovering(p, func(_ netip.Prefix, list []SpecialPurpose) bool {
+		out = append(out, list...)
+		return true
+	})
+	return out, nil
+}
+
+// IsGloballyReachable reports whether addr is globally reachable. As
+// RFC 8190 directs, the most specific registry entry decides; an
+// address outside every entry is globally reachable.
+func IsGloballyReachable(addr netip.Addr) (bool, error) {
+	sps, err := Classify(addr)
+	if err != nil {
+		return false, err
+	}
+	if len(sps) == 0 {
+		return true, nil
+	}
+	return sps[len(sps)-1].GloballyReachable, nil
+}
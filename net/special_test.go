@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestSpecialRegistry(t *testing.T) {
+	if v := SpecialRegistryVersion(); v == "" {
+		t.Error("expected a registry version")
+	}
+	sps, err := SpecialPurposes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sps) != strings.Count(specialCSV, "\n")-strings.Count(specialCSV, "#") {
+		t.Errorf("expected every registry line to be parsed, got %d entries", len(sps))
+	}
+	for _, sp := range sps {
+		if sp.Prefix != sp.Prefix.Masked() {
+			t.Errorf("%s: prefix has host bits set", sp.Prefix)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	testpairs := []struct {
+		input    string
+		expected string
+	}{
+		{input: "8.8.8.8", expected: ""},
+		{input: "10.1.2.3", expected: "Private-Use"},
+		{input: "100.64.0.1", expected: "Shared Address Space"},
+		{input: "127.0.0.1", expected: "Loopback"},
+		{input: "169.254.1.1", expected: "Link Local"},
+		{input: "0.0.0.0", expected: "This network,This host on this network"},
+		{input: "192.0.0.9", expected: "IETF Protocol Assignments,Port Control Protocol Anycast"},
+		{input: "192.0.2.1", expected: "Documentation (TEST-NET-1)"},
+		{input: "198.19.0.1", expected: "Benchmarking"},
+		{input: "224.0.0.251", expected: "Multicast,Local Network Control Block"},
+		{input: "255.255.255.255", expected: "Reserved,Limited Broadcast"},
+		{input: "2606:4700::1111", expected: ""},
+		{input: "::1", expected: "Loopback Address"},
+		{input: "::ffff:10.0.0.1", expected: "IPv4-mapped Address"},
+		{input: "fd00::1", expected: "Unique-Local"},
+		{input: "fe80::1%eth0", expected: "Link-Local Unicast"},
+		{input: "2001:db8::1", expected: "Documentation"},
+		{input: "2001:0:4136:e378::1", expected: "IETF Protocol Assignments,TEREDO"},
+		{input: "2002:c000:204::1", expected: "6to4"},
+		{input: "ff02::1", expected: "Multicast,Link-Local Scope Multicast"},
+	}
+
+	for _, pair := range testpairs {
+		sps, err := Classify(netip.MustParseAddr(pair.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, sp := range sps {
+			names = append(names, sp.Name)
+		}
+		if actual := strings.Join(names, ","); actual != pair.expected {
+			t.Errorf("%s: expected %q, got %q", pair.input, pair.expected, actual)
+		}
+	}
+
+	if _, err := Classify(netip.Addr{}); err == nil {
+		t.Error("expected error for invalid address")
+	}
+}
+
+func TestClassifyPrefix(t *testing.T) {
+	sps, err := ClassifyPrefix(netip.MustParsePrefix("100.100.0.0/16"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sps) != 1 || sps[0].Registry != RegistryIPv4Special || sps[0].RFC != "RFC 6598" || !sps[0].Forwardable || sps[0].GloballyReachable {
+		t.Errorf("expected Shared Address Space, got %+v", sps)
+	}
+
+	// A prefix straddling a registry entry is not classified by it.
+	if sps, err := ClassifyPrefix(netip.MustParsePrefix("10.0.0.0/7")); err != nil || len(sps) != 0 {
+		t.Errorf("expected no entries, got %+v %v", sps, err)
+	}
+}
+
+func TestIsGloballyReachable(t *testing.T) {
+	for addr, expected := range map[string]bool{
+		"8.8.8.8":      true,
+		"10.0.0.1":     false,
+		"192.0.0.9":    true,
+		"192.0.0.1":    false,
+		"2001:4860::1": true,
+		"2001:1::1":    true,
+		"2001:2::1":    false,
+		"fe80::1":      false,
+	} {
+		actual, err := IsGloballyReachable(netip.MustParseAddr(addr))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Errorf("%s: expected %v, got %v", addr, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"errors"
+	"math/big"
+	"net"
+	"net/netip"
+)
+
+// SubnetInfo describes the addresses of a subnet.
+//
+// For IPv4, the network and broadcast addresses are excluded from the
+// usable range, except for /31 point-to-point links (RFC 3021) and /32
+// host routes, where every address is usable and Broadcast is invalid.
+//
+// IPv6 has no broadcast address, so Broadcast is always invalid. The
+// first address of an IPv6 subnet is the Subnet-Router anycast address
+// (RFC 4291) and is excluded from the usable range, except for /127
+// point-to-point links (RFC 6164) and /128 host routes.
+type SubnetInfo struct {
+	Prefix    netip.Prefix // the masked prefix
+	Network   netip.Addr   // the first address in the prefix
+	Broadcast netip.Addr   // the IPv4 broadcast address, if any
+	Anycast   netip.Addr   // the IPv6 Subnet-Router anycast address, if any
+	First     netip.Addr   // the first usable host address
+	Last      netip.Addr   // the last usable host address
+	Total     *big.Int     // the number of addresses in the prefix
+	Usable    *big.Int     // the number of usable host addresses
+}
+
+// NewSubnetInfo computes the SubnetInfo for p.
+func NewSubnetInfo(p netip.Prefix) (*SubnetInfo, error) {
+	total, err := NipsPrefix(p)
+	if err != nil {
+		return nil, err
+	}
+	p = p.Masked()
+	si := &SubnetInfo{
+		Prefix:  p,
+		Network: firstAddr(p),
+		First:   firstAddr(p),
+		Last:    lastAddr(p),
+		Total:   total,
+		Usable:  new(big.Int).Set(total),
+	}
+
+	hostbits := p.Addr().BitLen() - p.Bits()
+	switch {
+	case hostbits <= 1:
+		// Point-to-point links and host routes have no reserved addresses.
+	case p.Addr().Is4():
+		si.Broadcast = si.Last
+		si.First = si.First.Next()
+		si.Last = si.Last.Prev()
+		si.Usable.Sub(si.Usable, big.NewInt(2))
+	default:
+		si.Anycast = si.Network
+		si.First = si.First.Next()
+		si.Usable.Sub(si.Usable, big.NewInt(1))
+	}
+	return si, nil
+}
+
+// NewSubnetInfoFromIPNet computes the SubnetInfo for cidr.
+func NewSubnetInfoFromIPNet(cidr *net.IPNet) (*SubnetInfo, error) {
+	if cidr == nil {
+		return nil, errors.New("nil net.IPNet")
+	}
+	p, err := PrefixFromIPNet(cidr)
+	if err != nil {
+		return nil, err
+	}
+	return NewSubnetInfo(p)
+}
@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestNewSubnetInfo(t *testing.T) {
+	testcases := []struct {
+		input     string
+		prefix    string
+		broadcast string
+		anycast   string
+		first     string
+		last      string
+		total     string
+		usable    string
+	}{
+		{"10.1.2.3/8", "10.0.0.0/8", "10.255.255.255", "invalid IP", "10.0.0.1", "10.255.255.254", "16777216", "16777214"},
+		{"192.168.1.77/24", "192.168.1.0/24", "192.168.1.255", "invalid IP", "192.168.1.1", "192.168.1.254", "256", "254"},
+		{"192.168.1.77/30", "192.168.1.76/30", "192.168.1.79", "invalid IP", "192.168.1.77", "192.168.1.78", "4", "2"},
+		{"192.168.1.77/31", "192.168.1.76/31", "invalid IP", "invalid IP", "192.168.1.76", "192.168.1.77", "2", "2"},
+		{"192.168.1.77/32", "192.168.1.77/32", "invalid IP", "invalid IP", "192.168.1.77", "192.168.1.77", "1", "1"},
+		{"0.0.0.0/0", "0.0.0.0/0", "255.255.255.255", "invalid IP", "0.0.0.1", "255.255.255.254", "4294967296", "4294967294"},
+		{"2001:db8::1/64", "2001:db8::/64", "invalid IP", "2001:db8::", "2001:db8::1", "2001:db8::ffff:ffff:ffff:ffff", "18446744073709551616", "18446744073709551615"},
+		{"2001:db8::1/126", "2001:db8::/126", "invalid IP", "2001:db8::", "2001:db8::1", "2001:db8::3", "4", "3"},
+		{"2001:db8::1/127", "2001:db8::/127", "invalid IP", "invalid IP", "2001:db8::", "2001:db8::1", "2", "2"},
+		{"2001:db8::1/128", "2001:db8::1/128", "invalid IP", "invalid IP", "2001:db8::1", "2001:db8::1", "1", "1"},
+	}
+
+	for _, tc := range testcases {
+		si, err := NewSubnetInfo(netip.MustParsePrefix(tc.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := []string{si.Prefix.String(), si.Broadcast.String(), si.Anycast.String(), si.First.String(), si.Last.String(), si.Total.String(), si.Usable.String()}
+		expected := []string{tc.prefix, tc.broadcast, tc.anycast, tc.first, tc.last, tc.total, tc.usable}
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Errorf("%s: expected %v, got %v", tc.input, expected, actual)
+				break
+			}
+		}
+		if si.Network != si.Prefix.Addr() {
+			t.Errorf("%s: expected network %v, got %v", tc.input, si.Prefix.Addr(), si.Network)
+		}
+	}
+}
+
+func TestNewSubnetInfoInvalid(t *testing.T) {
+	actual, err := NewSubnetInfo(netip.Prefix{})
+	if err == nil {
+		t.Error("Expected nil, got", actual)
+	}
+}
+
+func TestNewSubnetInfoFromIPNet(t *testing.T) {
+	_, ipn, err := net.ParseCIDR("172.16.0.0/12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	si, err := NewSubnetInfoFromIPNet(ipn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := Nipsv4(ipn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if si.Total.Uint64() != n {
+		t.Errorf("%s: expected %v, got %v", ipn, n, si.Total)
+	}
+	if si.Broadcast.String() != "172.31.255.255" {
+		t.Errorf("%s: expected 172.31.255.255, got %v", ipn, si.Broadcast)
+	}
+
+	if actual, err := NewSubnetInfoFromIPNet(nil); err == nil {
+		t.Error("Expected nil, got", actual)
+	}
+}
@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"net/netip"
+	"slices"
+)
+
+// maxSubnetBits bounds the number of prefixes Subnets will
+// materialize to 2^maxSubnetBits.
+const maxSubnetBits = 24
+
+// Subnets divides p into every subnet of length bits, in ascending
+// order.
+func Subnets(p netip.Prefix, bits int) ([]netip.Prefix, error) {
+	if !p.IsValid() {
+		return nil, errors.New("invalid netip.Prefix")
+	}
+	if bits < p.Bits() || bits > p.Addr().BitLen() {
+		return nil, fmt.Errorf("cannot divide %s into /%d subnets", p, bits)
+	}
+	if bits-p.Bits() > maxSubnetBits {
+		return nil, fmt.Errorf("dividing %s into /%d subnets yields more than %d prefixes", p, bits, 1<<maxSubnetBits)
+	}
+	p = p.Masked()
+	is4 := p.Addr().Is4()
+	n := 1 << (bits - p.Bits())
+	step := uint128{0, 1}.lsh(uint(p.Addr().BitLen() - bits))
+	out := make([]netip.Prefix, 0, n)
+	cur := u128(p.Addr())
+	for range n {
+		out = append(out, netip.PrefixFrom(cur.addr(is4), bits))
+		cur, _ = cur.add(step)
+	}
+	return out, nil
+}
+
+// Split divides p into n equal subnets. Since subnets are aligned on
+// bit boundaries, n must be a power of two.
+func Split(p netip.Prefix, n int) ([]netip.Prefix, error) {
+	if n <= 0 || n&(n-1) != 0 {
+		return nil, fmt.Errorf("%d is not a power of two", n)
+	}
+	return Subnets(p, p.Bits()+bits.TrailingZeros(uint(n)))
+}
+
+// Requirement is a named request for a subnet with at least Hosts
+// usable host addresses.
+type Requirement struct {
+	Name  string
+	Hosts uint64
+}
+
+// Allocation is a subnet assigned to satisfy a Requirement.
+type Allocation struct {
+	Name   string
+	Hosts  uint64       // the number of hosts requested
+	Prefix netip.Prefix // the assigned subnet
+	Usable *big.Int     // the number of usable hosts in Prefix
+}
+
+// PlanVLSM carves parent into non-overlapping subnets satisfying reqs
+// using variable-length subnet masking. Each requirement receives the
+// smallest subnet whose usable host count, as reported by
+// NewSubnetInfo, is at least the number of hosts requested. Subnets
+// are allocated largest first, which keeps every one of them aligned,
+// and the allocations are returned in that order.
+func PlanVLSM(parent netip.Prefix, reqs []Requirement) ([]Allocation, error) {
+	if !parent.IsValid() {
+		return nil, errors.New("invalid netip.Prefix")
+	}
+	parent = parent.Masked()
+	width := parent.Addr().BitLen()
+
+	type sized struct {
+		Requirement
+		hostbits int
+	}
+	plan := make([]sized, len(reqs))
+	need := new(big.Int)
+	for i, r := range reqs {
+		if r.Hosts == 0 {
+			return nil, fmt.Errorf("%s: at least one host is required", r.Name)
+		}
+		hostbits := hostbitsFor(r.Hosts, parent.Addr().Is4())
+		if hostbits > width-parent.Bits() {
+			return nil, fmt.Errorf("%s: %d hosts do not fit in %s", r.Name, r.Hosts, parent)
+		}
+		plan[i] = sized{r, hostbits}
+		need.Add(need, new(big.Int).Lsh(big.NewInt(1), uint(hostbits)))
+	}
+	capacity, err := NipsPrefix(parent)
+	if err != nil {
+		return nil, err
+	}
+	if need.Cmp(capacity) > 0 {
+		return nil, fmt.Errorf("requirements need %s addresses but %s has only %s", need, parent, capacity)
+	}
+
+	slices.SortStableFunc(plan, func(a, b sized) int {
+		return cmp.Compare(b.hostbits, a.hostbits)
+	})
+	out := make([]Allocation, len(plan))
+	cur := u128(parent.Addr())
+	for i, s := range plan {
+		si, err := NewSubnetInfo(netip.PrefixFrom(cur.addr(parent.Addr().Is4()), width-s.hostbits))
+		if err != nil {
+			return nil, err
+		}
+		out[i] = Allocation{Name: s.Name, Hosts: s.Hosts, Prefix: si.Prefix, Usable: si.Usable}
+		cur, _ = cur.add(uint128{0, 1}.lsh(uint(s.hostbits)))
+	}
+	return out, nil
+}
+
+// hostbitsFor returns the smallest number of host bits giving a subnet
+// with at least hosts usable addresses. IPv4 subnets lose their network
+// and broadcast addresses, and IPv6 subnets their Subnet-Router anycast
+// address, except for point-to-point links and host routes.
+func hostbitsFor(hosts uint64, is4 bool) int {
+	if hosts <= 2 {
+		return int(hosts) - 1
+	}
+	reserved := uint64(1)
+	if is4 {
+		reserved = 2
+	}
+	switch {
+	case hosts == ^uint64(0)-reserved+1:
+		// Exactly the usable addresses of 64 host bits, although
+		// hosts+reserved-1 would overflow.
+		return 64
+	case hosts > ^uint64(0)-reserved+1:
+		return 65
+	}
+	return bits.Len64(hosts + reserved - 1)
+}
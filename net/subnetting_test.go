@@ -0,0 +1,181 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestSubnets(t *testing.T) {
+	testcases := []struct {
+		input    string
+		bits     int
+		expected string
+	}{
+		{input: "10.0.0.0/24", bits: 24, expected: "10.0.0.0/24"},
+		{input: "10.0.0.0/24", bits: 26, expected: "10.0.0.0/26 10.0.0.64/26 10.0.0.128/26 10.0.0.192/26"},
+		{input: "10.0.0.77/30", bits: 32, expected: "10.0.0.76/32 10.0.0.77/32 10.0.0.78/32 10.0.0.79/32"},
+		{input: "2001:db8::/46", bits: 48, expected: "2001:db8::/48 2001:db8:1::/48 2001:db8:2::/48 2001:db8:3::/48"},
+		{input: "::/0", bits: 1, expected: "::/1 8000::/1"},
+	}
+
+	for _, tc := range testcases {
+		actual, err := Subnets(netip.MustParsePrefix(tc.input), tc.bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if joinPrefixes(actual) != tc.expected {
+			t.Errorf("%s /%d: expected %v, got %v", tc.input, tc.bits, tc.expected, actual)
+		}
+	}
+}
+
+func TestSubnetsErrors(t *testing.T) {
+	testcases := []struct {
+		input netip.Prefix
+		bits  int
+	}{
+		{input: netip.Prefix{}, bits: 24},
+		{input: netip.MustParsePrefix("10.0.0.0/24"), bits: 23},
+		{input: netip.MustParsePrefix("10.0.0.0/24"), bits: 33},
+		{input: netip.MustParsePrefix("2001:db8::/32"), bits: 64},
+	}
+
+	for _, tc := range testcases {
+		actual, err := Subnets(tc.input, tc.bits)
+		if err == nil {
+			t.Errorf("%s /%d: expected error, got %v", tc.input, tc.bits, actual)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	actual, err := Split(netip.MustParsePrefix("172.16.0.0/12"), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "172.16.0.0/14 172.20.0.0/14 172.24.0.0/14 172.28.0.0/14"; joinPrefixes(actual) != expected {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	for _, n := range []int{0, -2, 3, 6} {
+		if actual, err := Split(netip.MustParsePrefix("172.16.0.0/12"), n); err == nil {
+			t.Errorf("%d: expected error, got %v", n, actual)
+		}
+	}
+}
+
+func TestPlanVLSM(t *testing.T) {
+	reqs := []Requirement{
+		{Name: "dmz", Hosts: 10},
+		{Name: "office", Hosts: 100},
+		{Name: "p2p", Hosts: 2},
+		{Name: "servers", Hosts: 50},
+		{Name: "lab", Hosts: 14},
+	}
+	actual, err := PlanVLSM(netip.MustParsePrefix("192.168.10.0/24"), reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		name   string
+		prefix string
+		usable string
+	}{
+		{"office", "192.168.10.0/25", "126"},
+		{"servers", "192.168.10.128/26", "62"},
+		{"dmz", "192.168.10.192/28", "14"},
+		{"lab", "192.168.10.208/28", "14"},
+		{"p2p", "192.168.10.224/31", "2"},
+	}
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	for i, a := range actual {
+		if a.Name != expected[i].name || a.Prefix.String() != expected[i].prefix || a.Usable.String() != expected[i].usable {
+			t.Errorf("%d: expected %v, got %v %v %v", i, expected[i], a.Name, a.Prefix, a.Usable)
+		}
+	}
+}
+
+func TestPlanVLSMIPv6(t *testing.T) {
+	reqs := []Requirement{
+		{Name: "a", Hosts: 1 << 20},
+		{Name: "b", Hosts: 1},
+		{Name: "c", Hosts: 3},
+	}
+	actual, err := PlanVLSM(netip.MustParsePrefix("2001:db8::/104"), reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "2001:db8::/107 2001:db8::20:0/126 2001:db8::20:4/128"
+	var prefixes []netip.Prefix
+	for _, a := range actual {
+		prefixes = append(prefixes, a.Prefix)
+	}
+	if joinPrefixes(prefixes) != expected {
+		t.Errorf("expected %v, got %v", expected, prefixes)
+	}
+}
+
+func TestHostbitsFor(t *testing.T) {
+	testpairs := []struct {
+		hosts    uint64
+		is4      bool
+		expected int
+	}{
+		{hosts: 1, is4: true, expected: 0},
+		{hosts: 2, is4: true, expected: 1},
+		{hosts: 3, is4: true, expected: 3},
+		{hosts: 254, is4: true, expected: 8},
+		{hosts: 255, is4: true, expected: 9},
+		{hosts: 3, expected: 2},
+		{hosts: 255, expected: 8},
+		{hosts: 256, expected: 9},
+		{hosts: 1 << 63, expected: 64},
+		{hosts: ^uint64(0) - 1, expected: 64},
+		{hosts: ^uint64(0), expected: 64},
+		{hosts: ^uint64(0) - 1, is4: true, expected: 64},
+		{hosts: ^uint64(0), is4: true, expected: 65},
+	}
+
+	for _, pair := range testpairs {
+		if actual := hostbitsFor(pair.hosts, pair.is4); actual != pair.expected {
+			t.Errorf("%d hosts (IPv4 %v): expected %d, got %d", pair.hosts, pair.is4, pair.expected, actual)
+		}
+	}
+
+	// A /64 holds 2^64-1 usable addresses.
+	actual, err := PlanVLSM(netip.MustParsePrefix("2001:db8::/64"), []Requirement{{Name: "a", Hosts: ^uint64(0)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual[0].Prefix.String() != "2001:db8::/64" || actual[0].Usable.String() != "18446744073709551615" {
+		t.Errorf("expected 2001:db8::/64 with 18446744073709551615 usable, got %v with %v", actual[0].Prefix, actual[0].Usable)
+	}
+}
+
+func TestPlanVLSMErrors(t *testing.T) {
+	testcases := []struct {
+		parent string
+		reqs   []Requirement
+	}{
+		{parent: "10.0.0.0/24", reqs: []Requirement{{Name: "a", Hosts: 255}}},
+		{parent: "10.0.0.0/24", reqs: []Requirement{{Name: "a", Hosts: 126}, {Name: "b", Hosts: 126}, {Name: "c", Hosts: 1}}},
+		{parent: "10.0.0.0/24", reqs: []Requirement{{Name: "a", Hosts: 0}}},
+		{parent: "2001:db8::/65", reqs: []Requirement{{Name: "a", Hosts: ^uint64(0)}}},
+	}
+
+	for _, tc := range testcases {
+		actual, err := PlanVLSM(netip.MustParsePrefix(tc.parent), tc.reqs)
+		if err == nil {
+			t.Errorf("%s %v: expected error, got %v", tc.parent, tc.reqs, actual)
+		}
+	}
+
+	if _, err := PlanVLSM(netip.Prefix{}, nil); err == nil {
+		t.Error("expected error for invalid prefix")
+	}
+}
@@ -0,0 +1,281 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"errors"
+	"net/netip"
+)
+
+// Table is a routing table mapping prefixes to values of type V. It is
+// implemented as a path-compressed binary trie per address family, so
+// lookups visit at most one node per distinct prefix length on the
+// path to the address. The zero value is an empty table. A Table is
+// not safe for concurrent use without external locking.
+type Table[V any] struct {
+	v4, v6 *node[V]
+	n      int
+}
+
+// node is a trie node. Nodes without a value exist only to join two
+// subtrees that diverge below them.
+type node[V any] struct {
+	key    uint128 // the masked prefix address
+	bits   int     // the prefix length
+	prefix netip.Prefix
+	value  V
+	set    bool
+	child  [2]*node[V]
+}
+
+// tableKey returns the trie key for p.
+func tableKey(p netip.Prefix) (uint128, int) {
+	return maskBits(u128(p.Addr()), p.Bits(), p.Addr().BitLen()), p.Bits()
+}
+
+// maskBits clears every bit of u after the first bits bits of a
+// width-bit integer.
+func maskBits(u uint128, bits, width int) uint128 {
+	return u.and(hostMask(width - bits).not())
+}
+
+// commonBits returns the number of leading bits a and b have in
+// common, up to a maximum of limit.
+func commonBits(a, b uint128, width, limit int) int {
+	return min(width-a.xor(b).bitLen(), limit)
+}
+
+// root returns a pointer to the root of the trie for addr's family.
+func (t *Table[V]) root(addr netip.Addr) **node[V] {
+	if addr.Is4() {
+		return &t.v4
+	}
+	return &t.v6
+}
+
+// Len returns the number of prefixes in t.
+func (t *Table[V]) Len() int {
+	return t.n
+}
+
+// Insert associates v with p, replacing any value already present.
+// The host bits of p are ignored.
+func (t *Table[V]) Insert(p netip.Prefix, v V) error {
+	if !p.IsValid() {
+		return errors.New("invalid netip.Prefix")
+	}
+	width := p.Addr().BitLen()
+	key, bits := tableKey(p)
+	leaf := &node[V]{key: key, bits: bits, prefix: p.Masked(), value: v, set: true}
+
+	pp := t.root(p.Addr())
+	for {
+		n := *pp
+		if n == nil {
+			*pp = leaf
+			t.n++
+			return nil
+		}
+		common := commonBits(n.key, key, width, min(n.bits, bits))
+		switch {
+		case common == n.bits && common == bits:
+			if !n.set {
+				t.n++
+			}
+			n.value, n.set = v, true
+			return nil
+		case common == n.bits:
+			pp = &n.child[key.bit(n.bits, width)]
+		case common == bits:
+			leaf.child[n.key.bit(bits, width)] = n
+			*pp = leaf
+			t.n++
+			return nil
+		default:
+			glue := &node[V]{key: maskBits(key, common, width), bits: common}
+			glue.prefix = netip.PrefixFrom(glue.key.addr(width == 32), common)
+			glue.child[key.bit(common, width)] = leaf
+			glue.child[n.key.bit(common, width)] = n
+			*pp = glue
+			t.n++
+			return nil
+		}
+	}
+}
+
+// find returns the address of the link pointing at p's node, along
+// with the link to its parent, or nil if p is not in t.
+func (t *Table[V]) find(p netip.Prefix) (pp, parent **node[V]) {
+	if !p.IsValid() {
+		return nil, nil
+	}
+	width := p.Addr().BitLen()
+	key, bits := tableKey(p)
+	pp = t.root(p.Addr())
+	for *pp != nil {
+		n := *pp
+		common := commonBits(n.key, key, width, min(n.bits, bits))
+		if common < n.bits {
+			return nil, nil
+		}
+		if n.bits == bits {
+			if !n.set {
+				return nil, nil
+			}
+			return pp, parent
+		}
+		parent, pp = pp, &n.child[key.bit(n.bits, width)]
+	}
+	return nil, nil
+}
+
+// Get returns the value associated with exactly p.
+func (t *Table[V]) Get(p netip.Prefix) (V, bool) {
+	pp, _ := t.find(p)
+	if pp == nil {
+		var zero V
+		return zero, false
+	}
+	return (*pp).value, true
+}
+
+// Delete removes p from t, reporting whether it was present.
+func (t *Table[V]) Delete(p netip.Prefix) bool {
+	pp, parent := t.find(p)
+	if pp == nil {
+		return false
+	}
+	t.n--
+	n := *pp
+	var zero V
+	n.value, n.set = zero, false
+	switch {
+	case n.child[0] != nil && n.child[1] != nil:
+		// Keep n as a glue node.
+		return true
+	case n.child[0] != nil:
+		*pp = n.child[0]
+	default:
+		*pp = n.child[1]
+	}
+	// Removing n may leave its parent as a glue node with a single
+	// child, which is redundant.
+	if parent != nil && *pp == nil {
+		if g := *parent; !g.set {
+			if g.child[0] != nil {
+				*parent = g.child[0]
+			} else {
+				*parent = g.child[1]
+			}
+		}
+	}
+	return true
+}
+
+// Lookup returns the longest prefix in t containing addr, along with
+// its value.
+func (t *Table[V]) Lookup(addr netip.Addr) (netip.Prefix, V, bool) {
+	var best *node[V]
+	if addr.IsValid() {
+		width := addr.BitLen()
+		key := u128(addr)
+		for n := *t.root(addr); n != nil; {
+			if commonBits(n.key, key, width, n.bits) < n.bits {
+				break
+			}
+			if n.set {
+				best = n
+			}
+			if n.bits == width {
+				break
+			}
+			n = n.child[key.bit(n.bits, width)]
+		}
+	}
+	if best == nil {
+		var zero V
+		return netip.Prefix{}, zero, false
+	}
+	return best.prefix, best.value, true
+}
+
+// LookupPrefix returns the longest prefix in t containing every
+// address in p, along with its value. The result may be p itself.
+func (t *Table[V]) LookupPrefix(p netip.Prefix) (netip.Prefix, V, bool) {
+	var (
+		best  netip.Prefix
+		value V
+		found bool
+	)
+	t.WalkCovering(p, func(q netip.Prefix, v V) bool {
+		best, value, found = q, v, true
+		return true
+	})
+	return best, value, found
+}
+
+// WalkCovering calls fn for every prefix in t containing every address
+// in p, from the shortest to the longest, including p itself if it is
+// present. The walk stops if fn returns false.
+func (t *Table[V]) WalkCovering(p netip.Prefix, fn func(netip.Prefix, V) bool) {
+	if !p.IsValid() {
+		return
+	}
+	width := p.Addr().BitLen()
+	key, bits := tableKey(p)
+	for n := *t.root(p.Addr()); n != nil && n.bits <= bits; {
+		if commonBits(n.key, key, width, n.bits) < n.bits {
+			return
+		}
+		if n.set && !fn(n.prefix, n.value) {
+			return
+		}
+		if n.bits == bits {
+			return
+		}
+		n = n.child[key.bit(n.bits, width)]
+	}
+}
+
+// WalkCovered calls fn for every prefix in t lying within p, including
+// p itself if it is present, in ascending order. The walk stops if fn
+// returns false.
+func (t *Table[V]) WalkCovered(p netip.Prefix, fn func(netip.Prefix, V) bool) {
+	if !p.IsValid() {
+		return
+	}
+	width := p.Addr().BitLen()
+	key, bits := tableKey(p)
+	for n := *t.root(p.Addr()); n != nil; {
+		common := commonBits(n.key, key, width, min(n.bits, bits))
+		if common == bits {
+			n.walk(fn)
+			return
+		}
+		if common < n.bits {
+			return
+		}
+		n = n.child[key.bit(n.bits, width)]
+	}
+}
+
+// Walk calls fn for every prefix in t in ascending order, IPv4 before
+// IPv6. The walk stops if fn returns false.
+func (t *Table[V]) Walk(fn func(netip.Prefix, V) bool) {
+	if t.v4.walk(fn) {
+		t.v6.walk(fn)
+	}
+}
+
+// walk performs a pre-order traversal of the subtree rooted at n,
+// reporting whether it ran to completion.
+func (n *node[V]) walk(fn func(netip.Prefix, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	if n.set && !fn(n.prefix, n.value) {
+		return false
+	}
+	return n.child[0].walk(fn) && n.child[1].walk(fn)
+}
@@ -0,0 +1,195 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"math/rand/v2"
+	"net/netip"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	var tbl Table[string]
+	for _, s := range []string{"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "192.168.0.0/16", "::/0", "2001:db8::/32", "2001:db8:1::/48"} {
+		if err := tbl.Insert(netip.MustParsePrefix(s), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tbl.Len() != 8 {
+		t.Errorf("expected 8 prefixes, got %d", tbl.Len())
+	}
+
+	testpairs := []struct {
+		input    string
+		expected string
+	}{
+		{input: "10.1.2.3", expected: "10.1.2.0/24"},
+		{input: "10.1.3.3", expected: "10.1.0.0/16"},
+		{input: "10.2.3.4", expected: "10.0.0.0/8"},
+		{input: "11.0.0.1", expected: "0.0.0.0/0"},
+		{input: "192.168.255.255", expected: "192.168.0.0/16"},
+		{input: "2001:db8:1::1", expected: "2001:db8:1::/48"},
+		{input: "2001:db8:2::1", expected: "2001:db8::/32"},
+		{input: "fe80::1%eth0", expected: "::/0"},
+	}
+	for _, pair := range testpairs {
+		p, v, ok := tbl.Lookup(netip.MustParseAddr(pair.input))
+		if !ok || p.String() != pair.expected || v != pair.expected {
+			t.Errorf("%s: expected %v, got %v %v %v", pair.input, pair.expected, p, v, ok)
+		}
+	}
+
+	if v, ok := tbl.Get(netip.MustParsePrefix("10.1.0.0/16")); !ok || v != "10.1.0.0/16" {
+		t.Errorf("expected 10.1.0.0/16, got %v %v", v, ok)
+	}
+	if v, ok := tbl.Get(netip.MustParsePrefix("10.1.0.0/17")); ok {
+		t.Errorf("expected no match, got %v", v)
+	}
+
+	if !tbl.Delete(netip.MustParsePrefix("10.1.0.0/16")) {
+		t.Error("expected 10.1.0.0/16 to be deleted")
+	}
+	if tbl.Delete(netip.MustParsePrefix("10.1.0.0/16")) {
+		t.Error("expected 10.1.0.0/16 to be absent")
+	}
+	if p, _, _ := tbl.Lookup(netip.MustParseAddr("10.1.3.3")); p.String() != "10.0.0.0/8" {
+		t.Errorf("expected 10.0.0.0/8, got %v", p)
+	}
+	if p, _, _ := tbl.Lookup(netip.MustParseAddr("10.1.2.3")); p.String() != "10.1.2.0/24" {
+		t.Errorf("expected 10.1.2.0/24, got %v", p)
+	}
+	if tbl.Len() != 7 {
+		t.Errorf("expected 7 prefixes, got %d", tbl.Len())
+	}
+
+	if err := tbl.Insert(netip.Prefix{}, ""); err == nil {
+		t.Error("expected error for invalid prefix")
+	}
+	if _, _, ok := tbl.Lookup(netip.Addr{}); ok {
+		t.Error("expected no match for invalid address")
+	}
+}
+
+func TestTableWalks(t *testing.T) {
+	var tbl Table[int]
+	inputs := []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.1.2.128/25", "10.2.0.0/16", "172.16.0.0/12", "2001:db8::/32"}
+	for i, s := range inputs {
+		if err := tbl.Insert(netip.MustParsePrefix(s), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	collect := func(walk func(func(netip.Prefix, int) bool)) []netip.Prefix {
+		var out []netip.Prefix
+		walk(func(p netip.Prefix, _ int) bool {
+			out = append(out, p)
+			return true
+		})
+		return out
+	}
+
+	if actual, expected := joinPrefixes(collect(tbl.Walk)), "10.0.0.0/8 10.1.0.0/16 10.1.2.0/24 10.1.2.128/25 10.2.0.0/16 172.16.0.0/12 2001:db8::/32"; actual != expected {
+		t.Errorf("walk: expected %v, got %v", expected, actual)
+	}
+
+	covering := func(fn func(netip.Prefix, int) bool) { tbl.WalkCovering(netip.MustParsePrefix("10.1.2.0/24"), fn) }
+	if actual, expected := joinPrefixes(collect(covering)), "10.0.0.0/8 10.1.0.0/16 10.1.2.0/24"; actual != expected {
+		t.Errorf("covering: expected %v, got %v", expected, actual)
+	}
+
+	covered := func(fn func(netip.Prefix, int) bool) { tbl.WalkCovered(netip.MustParsePrefix("10.0.0.0/14"), fn) }
+	if actual, expected := joinPrefixes(collect(covered)), "10.1.0.0/16 10.1.2.0/24 10.1.2.128/25 10.2.0.0/16"; actual != expected {
+		t.Errorf("covered: expected %v, got %v", expected, actual)
+	}
+
+	p, v, ok := tbl.LookupPrefix(netip.MustParsePrefix("10.1.2.0/26"))
+	if !ok || p.String() != "10.1.2.0/24" || v != 2 {
+		t.Errorf("expected 10.1.2.0/24, got %v %v %v", p, v, ok)
+	}
+
+	n := 0
+	tbl.Walk(func(netip.Prefix, int) bool {
+		n++
+		return n < 3
+	})
+	if n != 3 {
+		t.Errorf("expected the walk to stop after 3 prefixes, got %d", n)
+	}
+}
+
+// randomPrefix returns a random prefix of the given family.
+func randomPrefix(r *rand.Rand, is4 bool) netip.Prefix {
+	if is4 {
+		var b [4]byte
+		for i := range b {
+			b[i] = byte(r.UintN(256))
+		}
+		return netip.PrefixFrom(netip.AddrFrom4(b), 8+r.IntN(25)).Masked()
+	}
+	var b [16]byte
+	b[0], b[1] = 0x20, 0x01
+	for i := 2; i < 8; i++ {
+		b[i] = byte(r.UintN(256))
+	}
+	return netip.PrefixFrom(netip.AddrFrom16(b), 16+r.IntN(49)).Masked()
+}
+
+func TestTableAgainstLinearScan(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	var tbl Table[netip.Prefix]
+	var prefixes []netip.Prefix
+	for i := range 2000 {
+		p := randomPrefix(r, i%2 == 0)
+		prefixes = append(prefixes, p)
+		if err := tbl.Insert(p, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Delete a few to exercise node removal.
+	deleted := map[netip.Prefix]bool{}
+	for _, p := range prefixes[:300] {
+		tbl.Delete(p)
+		deleted[p] = true
+	}
+
+	for i := range 5000 {
+		addr := randomPrefix(r, i%2 == 0).Addr()
+		var expected netip.Prefix
+		for _, p := range prefixes {
+			if !deleted[p] && p.Contains(addr) && p.Bits() >= expected.Bits() {
+				expected = p
+			}
+		}
+		actual, v, ok := tbl.Lookup(addr)
+		if ok != expected.IsValid() || actual != expected || (ok && v != expected) {
+			t.Fatalf("%s: expected %v, got %v", addr, expected, actual)
+		}
+	}
+}
+
+func benchmarkTableLookup(b *testing.B, is4 bool) {
+	r := rand.New(rand.NewPCG(1, 2))
+	var tbl Table[int]
+	for i := range 500000 {
+		if err := tbl.Insert(randomPrefix(r, is4), i); err != nil {
+			b.Fatal(err)
+		}
+	}
+	addrs := make([]netip.Addr, 1024)
+	for i := range addrs {
+		addrs[i] = randomPrefix(r, is4).Addr()
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tbl.Lookup(addrs[i%len(addrs)])
+	}
+}
+
+func BenchmarkTableLookupv4(b *testing.B) {
+	benchmarkTableLookup(b, true)
+}
+
+func BenchmarkTableLookupv6(b *testing.B) {
+	benchmarkTableLookup(b, false)
+}
@@ -0,0 +1,190 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// NAT64WellKnownPrefix is the RFC 6052 Well-Known Prefix.
+var NAT64WellKnownPrefix = netip.MustParsePrefix("64:ff9b::/96")
+
+var (
+	prefix6to4   = netip.MustParsePrefix("2002::/16")
+	prefixTeredo = netip.MustParsePrefix("2001::/32")
+)
+
+// checkNAT64Prefix verifies that p is an IPv6 prefix of one of the
+// lengths allowed by RFC 6052.
+func checkNAT64Prefix(p netip.Prefix) error {
+	if !p.IsValid() || !p.Addr().Is6() {
+		return fmt.Errorf("%s is not an IPv6 prefix", p)
+	}
+	switch p.Bits() {
+	case 32, 40, 48, 56, 64, 96:
+		return nil
+	default:
+		return fmt.Errorf("%s: NAT64 prefixes must be /32, /40, /48, /56, /64 or /96", p)
+	}
+}
+
+// NAT64Embed embeds the IPv4 address v4 in the NAT64 prefix p as
+// described in RFC 6052. Bits 64 to 71 of the result are left zero,
+// as is the suffix.
+func NAT64Embed(p netip.Prefix, v4 netip.Addr) (netip.Addr, error) {
+	if err := checkNAT64Prefix(p); err != nil {
+		return netip.Addr{}, err
+	}
+	if !v4.Is4() {
+		return netip.Addr{}, fmt.Errorf("%s is not an IPv4 address", v4)
+	}
+	b := p.Masked().Addr().As16()
+	if b[8] != 0 {
+		return netip.Addr{}, fmt.Errorf("%s: bits 64 to 71 must be zero", p)
+	}
+	idx := p.Bits() / 8
+	for _, octet := range v4.As4() {
+		if idx == 8 {
+			idx++
+		}
+		b[idx] = octet
+		idx++
+	}
+	return netip.AddrFrom16(b), nil
+}
+
+// NAT64Extract extracts the IPv4 address embedded in addr, which must
+// lie within the NAT64 prefix p.
+func NAT64Extract(p netip.Prefix, addr netip.Addr) (netip.Addr, error) {
+	if err := checkNAT64Prefix(p); err != nil {
+		return netip.Addr{}, err
+	}
+	if !p.Contains(addr.WithZone("")) {
+		return netip.Addr{}, fmt.Errorf("%s is not within %s", addr, p)
+	}
+	b := addr.As16()
+	if b[8] != 0 {
+		return netip.Addr{}, fmt.Errorf("%s: bits 64 to 71 must be zero", addr)
+	}
+	var v4 [4]byte
+	idx := p.Bits() / 8
+	for i := range v4 {
+		if idx == 8 {
+			idx++
+		}
+		v4[i] = b[idx]
+		idx++
+	}
+	return netip.AddrFrom4(v4), nil
+}
+
+// Prefix6to4 returns the RFC 3056 6to4 prefix, 2002:V4ADDR::/48, for
+// the IPv4 address v4.
+func Prefix6to4(v4 netip.Addr) (netip.Prefix, error) {
+	if !v4.Is4() {
+		return netip.Prefix{}, fmt.Errorf("%s is not an IPv4 address", v4)
+	}
+	var b [16]byte
+	b[0], b[1] = 0x20, 0x02
+	a := v4.As4()
+	copy(b[2:6], a[:])
+	return netip.PrefixFrom(netip.AddrFrom16(b), 48), nil
+}
+
+// Decode6to4 extracts the IPv4 address from the 6to4 address addr.
+func Decode6to4(addr netip.Addr) (netip.Addr, error) {
+	if !prefix6to4.Contains(addr.WithZone("")) {
+		return netip.Addr{}, fmt.Errorf("%s is not a 6to4 address", addr)
+	}
+	b := addr.As16()
+	return netip.AddrFrom4([4]byte(b[2:6])), nil
+}
+
+// Teredo describes an RFC 4380 Teredo address.
+type Teredo struct {
+	Server netip.Addr // the Teredo server's IPv4 address
+	Client netip.Addr // the client's external IPv4 address
+	Port   uint16     // the client's external UDP port
+	Flags  uint16     // the flags field, e.g. TeredoCone
+}
+
+// TeredoCone is the flag marking a client behind a cone NAT.
+const TeredoCone = 0x8000
+
+// DecodeTeredo decodes the Teredo address addr. The client address
+// and port, which are obfuscated on the wire, are returned in the
+// clear.
+func DecodeTeredo(addr netip.Addr) (*Teredo, error) {
+	if !prefixTeredo.Contains(addr.WithZone("")) {
+		return nil, fmt.Errorf("%s is not a Teredo address", addr)
+	}
+	b := addr.As16()
+	var client [4]byte
+	for i := range client {
+		client[i] = b[12+i] ^ 0xff
+	}
+	return &Teredo{
+		Server: netip.AddrFrom4([4]byte(b[4:8])),
+		Client: netip.AddrFrom4(client),
+		Port:   binary.BigEndian.Uint16(b[10:12]) ^ 0xffff,
+		Flags:  binary.BigEndian.Uint16(b[8:10]),
+	}, nil
+}
+
+// Addr encodes t as a Teredo address.
+func (t Teredo) Addr() (netip.Addr, error) {
+	if !t.Server.Is4() || !t.Client.Is4() {
+		return netip.Addr{}, errors.New("Teredo server and client must be IPv4 addresses")
+	}
+	b := prefixTeredo.Addr().As16()
+	server, client := t.Server.As4(), t.Client.As4()
+	copy(b[4:8], server[:])
+	binary.BigEndian.PutUint16(b[8:10], t.Flags)
+	binary.BigEndian.PutUint16(b[10:12], t.Port^0xffff)
+	for i := range client {
+		b[12+i] = client[i] ^ 0xff
+	}
+	return netip.AddrFrom16(b), nil
+}
+
+// ISATAPAddr returns the RFC 5214 ISATAP address for the IPv4 address
+// v4 within the /64 prefix p. The universal/local bit of the interface
+// identifier is set when v4 is globally reachable.
+func ISATAPAddr(p netip.Prefix, v4 netip.Addr) (netip.Addr, error) {
+	if !p.IsValid() || !p.Addr().Is6() || p.Bits() != 64 {
+		return netip.Addr{}, fmt.Errorf("%s is not an IPv6 /64 prefix", p)
+	}
+	if !v4.Is4() {
+		return netip.Addr{}, fmt.Errorf("%s is not an IPv4 address", v4)
+	}
+	global, err := IsGloballyReachable(v4)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	b := p.Masked().Addr().As16()
+	if global {
+		b[8] = 0x02
+	}
+	b[10], b[11] = 0x5e, 0xfe
+	a := v4.As4()
+	copy(b[12:], a[:])
+	return netip.AddrFrom16(b), nil
+}
+
+// DecodeISATAP extracts the IPv4 address from the ISATAP address addr.
+func DecodeISATAP(addr netip.Addr) (netip.Addr, error) {
+	if !addr.Is6() || addr.Is4In6() {
+		return netip.Addr{}, fmt.Errorf("%s is not an IPv6 address", addr)
+	}
+	b := addr.As16()
+	// RFC 5214 defines only the local (0x00) and universal (0x02) forms
+	// of the first octet of the interface identifier.
+	if (b[8] != 0x00 && b[8] != 0x02) || b[9] != 0 || b[10] != 0x5e || b[11] != 0xfe {
+		return netip.Addr{}, fmt.Errorf("%s is not an ISATAP address", addr)
+	}
+	return netip.AddrFrom4([4]byte(b[12:16])), nil
+}
@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestNAT64(t *testing.T) {
+	// The examples from RFC 6052, section 2.4.
+	v4 := netip.MustParseAddr("192.0.2.33")
+	testpairs := []struct {
+		prefix   string
+		expected string
+	}{
+		{prefix: "2001:db8::/32", expected: "2001:db8:c000:221::"},
+		{prefix: "2001:db8:100::/40", expected: "2001:db8:1c0:2:21::"},
+		{prefix: "2001:db8:122::/48", expected: "2001:db8:122:c000:2:2100::"},
+		{prefix: "2001:db8:122:300::/56", expected: "2001:db8:122:3c0:0:221::"},
+		{prefix: "2001:db8:122:344::/64", expected: "2001:db8:122:344:c0:2:2100:0"},
+		{prefix: "2001:db8:122:344::/96", expected: "2001:db8:122:344::c000:221"},
+		{prefix: "64:ff9b::/96", expected: "64:ff9b::c000:221"},
+	}
+
+	for _, pair := range testpairs {
+		p := netip.MustParsePrefix(pair.prefix)
+		actual, err := NAT64Embed(p, v4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.String() != pair.expected {
+			t.Errorf("%s: expected %v, got %v", p, pair.expected, actual)
+		}
+		back, err := NAT64Extract(p, actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if back != v4 {
+			t.Errorf("%s: expected %v, got %v", actual, v4, back)
+		}
+	}
+
+	if actual, _ := NAT64Embed(NAT64WellKnownPrefix, v4); FormatDottedQuad(actual) != "64:ff9b::192.0.2.33" {
+		t.Errorf("expected 64:ff9b::192.0.2.33, got %v", FormatDottedQuad(actual))
+	}
+}
+
+func TestNAT64Errors(t *testing.T) {
+	v4 := netip.MustParseAddr("192.0.2.33")
+	for _, p := range []string{"2001:db8::/36", "10.0.0.0/8", "2001:db8:0:0:ff00::/96"} {
+		if actual, err := NAT64Embed(netip.MustParsePrefix(p), v4); err == nil {
+			t.Errorf("%s: expected error, got %v", p, actual)
+		}
+	}
+	if actual, err := NAT64Embed(NAT64WellKnownPrefix, netip.MustParseAddr("::1")); err == nil {
+		t.Errorf("expected error, got %v", actual)
+	}
+
+	testpairs := []struct {
+		prefix string
+		addr   string
+	}{
+		{prefix: "2001:db8::/32", addr: "2001:db9:c000:221::"},
+		{prefix: "2001:db8::/32", addr: "2001:db8:c000:221:ff00::"},
+		{prefix: "2001:db8::/33", addr: "2001:db8:c000:221::"},
+	}
+	for _, pair := range testpairs {
+		if actual, err := NAT64Extract(netip.MustParsePrefix(pair.prefix), netip.MustParseAddr(pair.addr)); err == nil {
+			t.Errorf("%s %s: expected error, got %v", pair.prefix, pair.addr, actual)
+		}
+	}
+}
+
+func Test6to4(t *testing.T) {
+	p, err := Prefix6to4(netip.MustParseAddr("192.0.2.4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.String() != "2002:c000:204::/48" {
+		t.Errorf("expected 2002:c000:204::/48, got %v", p)
+	}
+	v4, err := Decode6to4(netip.MustParseAddr("2002:c000:204:1::1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v4.String() != "192.0.2.4" {
+		t.Errorf("expected 192.0.2.4, got %v", v4)
+	}
+
+	if actual, err := Decode6to4(netip.MustParseAddr("2001:db8::1")); err == nil {
+		t.Errorf("expected error, got %v", actual)
+	}
+	if actual, err := Prefix6to4(netip.MustParseAddr("::1")); err == nil {
+		t.Errorf("expected error, got %v", actual)
+	}
+}
+
+func TestTeredo(t *testing.T) {
+	// The example from RFC 4380, section 4.
+	addr := netip.MustParseAddr("2001:0000:4136:e378:8000:63bf:3fff:fdd2")
+	td, err := DecodeTeredo(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if td.Server.String() != "65.54.227.120" || td.Client.String() != "192.0.2.45" || td.Port != 40000 || td.Flags != TeredoCone {
+		t.Errorf("%s: unexpected decoding %+v", addr, td)
+	}
+	back, err := td.Addr()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back != addr {
+		t.Errorf("expected %v, got %v", addr, back)
+	}
+
+	if actual, err := DecodeTeredo(netip.MustParseAddr("2001:db8::1")); err == nil {
+		t.Errorf("expected error, got %v", actual)
+	}
+	if actual, err := (Teredo{}).Addr(); err == nil {
+		t.Errorf("expected error, got %v", actual)
+	}
+}
+
+func TestISATAP(t *testing.T) {
+	testpairs := []struct {
+		v4       string
+		expected string
+	}{
+		{v4: "192.168.1.1", expected: "fe80::5efe:c0a8:101"},
+		{v4: "8.8.8.8", expected: "fe80::200:5efe:808:808"},
+	}
+
+	p := netip.MustParsePrefix("fe80::/64")
+	for _, pair := range testpairs {
+		actual, err := ISATAPAddr(p, netip.MustParseAddr(pair.v4))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.String() != pair.expected {
+			t.Errorf("%s: expected %v, got %v", pair.v4, pair.expected, actual)
+		}
+		v4, err := DecodeISATAP(actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v4.String() != pair.v4 {
+			t.Errorf("%s: expected %v, got %v", actual, pair.v4, v4)
+		}
+	}
+
+	// The group bit is never set in an ISATAP interface identifier.
+	for _, s := range []string{"fe80::1", "fe80::100:5efe:c0a8:101", "fe80::300:5efe:808:808"} {
+		if actual, err := DecodeISATAP(netip.MustParseAddr(s)); err == nil {
+			t.Errorf("%s: expected error, got %v", s, actual)
+		}
+	}
+	if actual, err := ISATAPAddr(netip.MustParsePrefix("fe80::/48"), netip.MustParseAddr("8.8.8.8")); err == nil {
+		t.Errorf("expected error, got %v", actual)
+	}
+}
@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+	"net/netip"
+)
+
+// uint128 is an unsigned 128-bit integer used for address arithmetic.
+// IPv4 addresses occupy the low 32 bits.
+type uint128 struct {
+	hi, lo uint64
+}
+
+// u128 returns the integer value of addr.
+func u128(addr netip.Addr) uint128 {
+	if addr.Is4() {
+		b := addr.As4()
+		return uint128{0, uint64(binary.BigEndian.Uint32(b[:]))}
+	}
+	b := addr.As16()
+	return uint128{binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])}
+}
+
+// addr returns the address whose integer value is u. When is4 is set,
+// only the low 32 bits of u are used.
+func (u uint128) addr(is4 bool) netip.Addr {
+	if is4 {
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], uint32(u.lo))
+		return netip.AddrFrom4(b)
+	}
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], u.hi)
+	binary.BigEndian.PutUint64(b[8:], u.lo)
+	return netip.AddrFrom16(b)
+}
+
+// hostMask returns a uint128 with the low n bits set.
+func hostMask(n int) uint128 {
+	switch {
+	case n <= 0:
+		return uint128{}
+	case n < 64:
+		return uint128{0, 1<<n - 1}
+	case n < 128:
+		return uint128{1<<(n-64) - 1, ^uint64(0)}
+	default:
+		return uint128{^uint64(0), ^uint64(0)}
+	}
+}
+
+func (u uint128) and(v uint128) uint128 { return uint128{u.hi & v.hi, u.lo & v.lo} }
+func (u uint128) or(v uint128) uint128  { return uint128{u.hi | v.hi, u.lo | v.lo} }
+func (u uint128) xor(v uint128) uint128 { return uint128{u.hi ^ v.hi, u.lo ^ v.lo} }
+func (u uint128) not() uint128          { return uint128{^u.hi, ^u.lo} }
+func (u uint128) isZero() bool          { return u.hi == 0 && u.lo == 0 }
+
+// cmp returns -1, 0 or +1 depending on whether u is less than, equal
+// to or greater than v.
+func (u uint128) cmp(v uint128) int {
+	switch {
+	case u.hi < v.hi:
+		return -1
+	case u.hi > v.hi:
+		return 1
+	case u.lo < v.lo:
+		return -1
+	case u.lo > v.lo:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// add returns u+v and whether the addition overflowed.
+func (u uint128) add(v uint128) (uint128, bool) {
+	lo, carry := bits.Add64(u.lo, v.lo, 0)
+	hi, carry := bits.Add64(u.hi, v.hi, carry)
+	return uint128{hi, lo}, carry != 0
+}
+
+// sub returns u-v and whether the subtraction underflowed.
+func (u uint128) sub(v uint128) (uint128, bool) {
+	lo, borrow := bits.Sub64(u.lo, v.lo, 0)
+	hi, borrow := bits.Sub64(u.hi, v.hi, borrow)
+	return uint128{hi, lo}, borrow != 0
+}
+
+// lsh returns u shifted left by n bits.
+func (u uint128) lsh(n uint) uint128 {
+	switch {
+	case n >= 128:
+		return uint128{}
+	case n >= 64:
+		return uint128{u.lo << (n - 64), 0}
+	default:
+		return uint128{u.hi<<n | u.lo>>(64-n), u.lo << n}
+	}
+}
+
+// rsh returns u shifted right by n bits.
+func (u uint128) rsh(n uint) uint128 {
+	switch {
+	case n >= 128:
+		return uint128{}
+	case n >= 64:
+		return uint128{0, u.hi >> (n - 64)}
+	default:
+		return uint128{u.hi >> n, u.lo>>n | u.hi<<(64-n)}
+	}
+}
+
+// bit returns bit i of u, counting from the most significant bit of
+// a width-bit integer.
+func (u uint128) bit(i, width int) uint {
+	n := uint(width - 1 - i)
+	if n >= 64 {
+		return uint(u.hi>>(n-64)) & 1
+	}
+	return uint(u.lo>>n) & 1
+}
+
+// trailingZeros returns the number of trailing zero bits in u, or 128
+// if u is zero.
+func (u uint128) trailingZeros() int {
+	if u.lo != 0 {
+		return bits.TrailingZeros64(u.lo)
+	}
+	return 64 + bits.TrailingZeros64(u.hi)
+}
+
+// bitLen returns the number of bits required to represent u.
+func (u uint128) bitLen() int {
+	if u.hi != 0 {
+		return 64 + bits.Len64(u.hi)
+	}
+	return bits.Len64(u.lo)
+}
+
+// big returns u as a big.Int.
+func (u uint128) big() *big.Int {
+	n := new(big.Int).SetUint64(u.hi)
+	n.Lsh(n, 64)
+	return n.Or(n, new(big.Int).SetUint64(u.lo))
+}
+
+// u128FromBig converts n into a uint128, reporting false if n is
+// negative or does not fit in 128 bits.
+func u128FromBig(n *big.Int) (uint128, bool) {
+	if n.Sign() < 0 || n.BitLen() > 128 {
+		return uint128{}, false
+	}
+	lo := new(big.Int).And(n, new(big.Int).SetUint64(^uint64(0)))
+	hi := new(big.Int).Rsh(n, 64)
+	return uint128{hi.Uint64(), lo.Uint64()}, true
+}
+
+// firstAddr returns the first address in p.
+func firstAddr(p netip.Prefix) netip.Addr {
+	return p.Masked().Addr()
+}
+
+// lastAddr returns the last address in p.
+func lastAddr(p netip.Prefix) netip.Addr {
+	a := p.Addr()
+	return u128(a).or(hostMask(a.BitLen() - p.Bits())).addr(a.Is4())
+}
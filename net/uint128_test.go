@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: © 2019 Ramsey Dow <yesmar@gmail.com>
+// SPDX-License-Identifier: BSD-2-Clause
+
+package net
+
+import (
+	"math/big"
+	"net/netip"
+	"testing"
+)
+
+func TestU128RoundTrip(t *testing.T) {
+	for _, input := range []string{"0.0.0.0", "1.2.3.4", "255.255.255.255", "::", "2001:db8::1", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", "::ffff:1.2.3.4"} {
+		addr := netip.MustParseAddr(input)
+		actual := u128(addr).addr(addr.Is4())
+		if actual != addr {
+			t.Errorf("%s: expected %v, got %v", input, addr, actual)
+		}
+	}
+}
+
+func TestU128Arithmetic(t *testing.T) {
+	allOnes := uint128{^uint64(0), ^uint64(0)}
+	one := uint128{0, 1}
+
+	if sum, carry := (uint128{0, ^uint64(0)}).add(one); carry || sum != (uint128{1, 0}) {
+		t.Errorf("expected {1 0} without carry, got %v %v", sum, carry)
+	}
+	if _, carry := allOnes.add(one); !carry {
+		t.Error("expected carry")
+	}
+	if diff, borrow := (uint128{1, 0}).sub(one); borrow || diff != (uint128{0, ^uint64(0)}) {
+		t.Errorf("expected {0 max} without borrow, got %v %v", diff, borrow)
+	}
+	if _, borrow := (uint128{}).sub(one); !borrow {
+		t.Error("expected borrow")
+	}
+	if actual := one.lsh(127).rsh(127); actual != one {
+		t.Errorf("expected %v, got %v", one, actual)
+	}
+	if actual := one.lsh(64); actual != (uint128{1, 0}) {
+		t.Errorf("expected {1 0}, got %v", actual)
+	}
+	if actual := hostMask(64); actual != (uint128{0, ^uint64(0)}) {
+		t.Errorf("expected {0 max}, got %v", actual)
+	}
+	if actual := hostMask(128); actual != allOnes {
+		t.Errorf("expected %v, got %v", allOnes, actual)
+	}
+	if actual := (uint128{1, 0}).trailingZeros(); actual != 64 {
+		t.Errorf("expected 64, got %v", actual)
+	}
+	if actual := (uint128{1, 0}).bit(63, 128); actual != 1 {
+		t.Errorf("expected 1, got %v", actual)
+	}
+}
+
+func TestU128Big(t *testing.T) {
+	allOnes := uint128{^uint64(0), ^uint64(0)}
+	expected, _ := new(big.Int).SetString("340282366920938463463374607431768211455", 10)
+	if actual := allOnes.big(); actual.Cmp(expected) != 0 {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if actual, ok := u128FromBig(expected); !ok || actual != allOnes {
+		t.Errorf("expected %v, got %v", allOnes, actual)
+	}
+	if _, ok := u128FromBig(new(big.Int).Add(expected, big.NewInt(1))); ok {
+		t.Error("expected overflow")
+	}
+	if _, ok := u128FromBig(big.NewInt(-1)); ok {
+		t.Error("expected failure for negative value")
+	}
+}
+
+func TestLastAddr(t *testing.T) {
+	testpairs := []struct {
+		input    string
+		expected string
+	}{
+		{input: "10.0.0.0/8", expected: "10.255.255.255"},
+		{input: "10.1.2.3/24", expected: "10.1.2.255"},
+		{input: "0.0.0.0/0", expected: "255.255.255.255"},
+		{input: "1.2.3.4/32", expected: "1.2.3.4"},
+		{input: "2001:db8::/32", expected: "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff"},
+		{input: "::/0", expected: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"},
+	}
+
+	for _, pair := range testpairs {
+		actual := lastAddr(netip.MustParsePrefix(pair.input))
+		if actual.String() != pair.expected {
+			t.Errorf("%s: expected %v, got %v", pair.input, pair.expected, actual)
+		}
+	}
+}